@@ -3,9 +3,11 @@ package actionners
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strconv"
 	"strings"
+	"time"
 
 	"go.opentelemetry.io/otel/codes"
 
@@ -18,6 +20,7 @@ import (
 	"go.opentelemetry.io/otel/trace"
 
 	lambdaInvoke "github.com/falcosecurity/falco-talon/actionners/aws/lambda"
+	calicoBlocklist "github.com/falcosecurity/falco-talon/actionners/calico/blocklist"
 	calicoNetworkpolicy "github.com/falcosecurity/falco-talon/actionners/calico/networkpolicy"
 	ciliumNetworkpolicy "github.com/falcosecurity/falco-talon/actionners/cilium/networkpolicy"
 	k8sCordon "github.com/falcosecurity/falco-talon/actionners/kubernetes/cordon"
@@ -28,12 +31,15 @@ import (
 	k8sLabel "github.com/falcosecurity/falco-talon/actionners/kubernetes/label"
 	k8sLog "github.com/falcosecurity/falco-talon/actionners/kubernetes/log"
 	k8sNetworkpolicy "github.com/falcosecurity/falco-talon/actionners/kubernetes/networkpolicy"
+	k8sQuarantine "github.com/falcosecurity/falco-talon/actionners/kubernetes/quarantine"
+	k8sScale "github.com/falcosecurity/falco-talon/actionners/kubernetes/scale"
 	k8sScript "github.com/falcosecurity/falco-talon/actionners/kubernetes/script"
 	k8sTcpdump "github.com/falcosecurity/falco-talon/actionners/kubernetes/tcpdump"
 	k8sTerminate "github.com/falcosecurity/falco-talon/actionners/kubernetes/terminate"
 	"github.com/falcosecurity/falco-talon/configuration"
-	talonContext "github.com/falcosecurity/falco-talon/internal/context"
+	"github.com/falcosecurity/falco-talon/internal/audit"
 	"github.com/falcosecurity/falco-talon/internal/events"
+	"github.com/falcosecurity/falco-talon/internal/history"
 	"github.com/falcosecurity/falco-talon/internal/nats"
 	"github.com/falcosecurity/falco-talon/internal/otlp/metrics"
 	"github.com/falcosecurity/falco-talon/internal/rules"
@@ -43,13 +49,18 @@ import (
 
 type Actionner interface {
 	Init() error
-	Run(event *events.Event, action *rules.Action) (utils.LogLine, *models.Data, error)
+	Run(ctx context.Context, event *events.Event, action *rules.Action) (utils.LogLine, *models.Data, error)
 	CheckParameters(action *rules.Action) error
 	Checks(event *events.Event, action *rules.Action) error
 	Information() models.Information
 	Parameters() models.Parameters
 }
 
+// Rollbacker is implemented by the actionners able to revert their changes
+type Rollbacker interface {
+	Rollback(ctx context.Context, state []byte) (utils.LogLine, error)
+}
+
 type Actionners []Actionner
 
 var defaultActionners *Actionners
@@ -80,8 +91,11 @@ func ListDefaultActionners() *Actionners {
 			k8sDrain.Register(),
 			k8sDownload.Register(),
 			k8sTcpdump.Register(),
+			k8sQuarantine.Register(),
+			k8sScale.Register(),
 			lambdaInvoke.Register(),
 			calicoNetworkpolicy.Register(),
+			calicoBlocklist.Register(),
 			ciliumNetworkpolicy.Register(),
 		)
 	}
@@ -174,6 +188,12 @@ func runAction(mctx context.Context, rule *rules.Rule, action *rules.Action, eve
 		return err
 	}
 
+	if action.RequireApproval() && !isApproved(mctx, action) {
+		return parkAction(mctx, rule, action, event, log)
+	}
+
+	action = action.Render(event)
+
 	actionner := actionners.FindActionner(action.GetActionner())
 	if actionner == nil {
 		log.Status = utils.FailureStr
@@ -219,7 +239,27 @@ func runAction(mctx context.Context, rule *rules.Rule, action *rules.Action, eve
 		trace.WithAttributes(attribute.String("actionner.name", action.GetActionnerName())),
 	)
 	defer span.End()
-	result, data, err := actionner.Run(event, action)
+
+	rctx := actx
+	if timeout := action.GetTimeout(); timeout > 0 {
+		var cancel context.CancelFunc
+		rctx, cancel = context.WithTimeout(actx, timeout)
+		defer cancel()
+		span.SetAttributes(attribute.String("action.timeout", timeout.String()))
+	}
+	var result utils.LogLine
+	var data *models.Data
+	var attempts int
+	err = withRetry(rctx, action.Retry, log, func(ctx context.Context) error {
+		var err2 error
+		attempts++
+		result, data, err2 = actionner.Run(ctx, event, action)
+		// the changes of a failed attempt are recorded too, to be able to revert them
+		if data != nil && len(data.Rollback) != 0 {
+			recordChange(rule, action, event, data.Rollback)
+		}
+		return err2
+	})
 	span.SetAttributes(attribute.String("action.result", result.Status))
 	span.SetAttributes(attribute.String("action.output", result.Output))
 
@@ -240,19 +280,27 @@ func runAction(mctx context.Context, rule *rules.Rule, action *rules.Action, eve
 	}
 
 	output := action.GetOutput()
-	if output == nil && data != nil {
+	if output == nil && data != nil && len(data.Bytes) != 0 {
 		log.Output = string(data.Bytes)
 	}
 
+	if err != nil && errors.Is(rctx.Err(), context.DeadlineExceeded) {
+		err = fmt.Errorf("timeout of %v reached: %w", action.GetTimeout(), err)
+		log.Status = utils.TimeoutStr
+	}
+
 	metrics.IncreaseCounter(log)
 
 	if err != nil {
-		log.Status = utils.FailureStr
+		if log.Status != utils.TimeoutStr {
+			log.Status = utils.FailureStr
+		}
 		log.Error = err.Error()
 		span.SetStatus(codes.Error, err.Error())
 		span.RecordError(err)
 		utils.PrintLog("error", log)
 		go notifiers.Notify(actx, rule, action, event, log)
+		recordFailure(mctx, rule, action, event, log, attempts)
 		return err
 	}
 	log.Status = utils.SuccessStr
@@ -334,7 +382,12 @@ func runAction(mctx context.Context, rule *rules.Rule, action *rules.Action, eve
 			return err
 		}
 
-		result, err = o.Run(output, data)
+		rendered := output.Render(event, data.Objects)
+		err = withRetry(octx, output.Retry, log, func(context.Context) error {
+			var err2 error
+			result, err2 = o.Run(rendered, data)
+			return err2
+		})
 		log.Status = result.Status
 		log.Objects = result.Objects
 		if result.Output != "" {
@@ -413,7 +466,12 @@ func runAction(mctx context.Context, rule *rules.Rule, action *rules.Action, eve
 			return err
 		}
 
-		result, err = o.Run(output, data)
+		rendered := output.Render(event, data.Objects)
+		err = withRetry(octx, output.Retry, log, func(context.Context) error {
+			var err2 error
+			result, err2 = o.Run(rendered, data)
+			return err2
+		})
 		log.Status = result.Status
 		log.Objects = result.Objects
 		if result.Output != "" {
@@ -449,105 +507,140 @@ func runAction(mctx context.Context, rule *rules.Rule, action *rules.Action, eve
 	return nil
 }
 
+// StartConsumer processes the events with a pool of workers, the number of workers is set by the 'workers' setting
 func StartConsumer(eventsC <-chan nats.MessageWithContext) {
 	config := configuration.GetConfiguration()
+	workers := config.Workers
+	if workers < 1 {
+		workers = 1
+	}
+	for range workers - 1 {
+		go consume(eventsC)
+	}
+	consume(eventsC)
+}
+
+func consume(eventsC <-chan nats.MessageWithContext) {
 	for {
 		m := <-eventsC
-		e := m.Data
-		ectx := m.Ctx
 		var event *events.Event
-		err := json.Unmarshal(e, &event)
-		if err != nil {
-			continue
-		}
-		if event == nil {
+		err := json.Unmarshal(m.Data, &event)
+		if err != nil || event == nil {
+			// the message will never be processed, no need for a new delivery
+			_ = m.Term()
 			continue
 		}
 
-		log := utils.LogLine{
-			Message:  "event",
-			Event:    event.Rule,
-			Priority: event.Priority,
-			Output:   event.Output,
-			Source:   event.Source,
-			TraceID:  event.TraceID,
+		if err := processMsg(m, event); err != nil {
+			// the failed actions have been recorded as dead letters, they're redriven rather than the whole event
+			utils.PrintLog("error", utils.LogLine{Error: err.Error(), TraceID: event.TraceID, Message: "event"})
 		}
+		if err := m.Ack(); err != nil {
+			utils.PrintLog("error", utils.LogLine{Error: err.Error(), TraceID: event.TraceID, Message: "event"})
+		}
+	}
+}
 
-		enabledRules := rules.GetRules()
-		triggeredRules := make([]*rules.Rule, 0)
-		for _, i := range *enabledRules {
-			if i.CompareRule(event) {
-				triggeredRules = append(triggeredRules, i)
+// processMsg processes an event and keeps its message in progress meanwhile, to avoid a new delivery
+// before the end, the errors of the actions and a panic are returned
+func processMsg(m nats.MessageWithContext, event *events.Event) (err error) {
+	config := configuration.GetConfiguration()
+	if config.NATS.AckAfterProcessing && config.NATS.AckWaitSeconds > 0 {
+		ticker := time.NewTicker(time.Duration(config.NATS.AckWaitSeconds) * time.Second / 2)
+		defer ticker.Stop()
+		done := make(chan struct{})
+		defer close(done)
+		go func() {
+			for {
+				select {
+				case <-done:
+					return
+				case <-ticker.C:
+					_ = m.InProgress()
+				}
 			}
-		}
+		}()
+	}
 
-		if len(triggeredRules) == 0 {
-			continue
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("error while processing the event: %v", r)
 		}
+	}()
 
-		if !config.PrintAllEvents {
-			utils.PrintLog("info", log)
+	return processEvent(m.Ctx, event)
+}
+
+func processEvent(ectx context.Context, event *events.Event) error {
+	config := configuration.GetConfiguration()
+	log := utils.LogLine{
+		Message:  "event",
+		Event:    event.Rule,
+		Priority: event.Priority,
+		Output:   event.Output,
+		Source:   event.Source,
+		TraceID:  event.TraceID,
+	}
+
+	enabledRules := rules.GetRules()
+	triggeredRules := make([]*rules.Rule, 0)
+	for _, i := range *enabledRules {
+		if i.CompareRule(event) {
+			triggeredRules = append(triggeredRules, i)
 		}
+	}
 
-		for _, i := range triggeredRules {
-			log.Message = "match"
-			log.Rule = i.GetName()
-
-			tracer := traces.GetTracer()
-			mctx, span := tracer.Start(ectx, "match",
-				trace.WithAttributes(attribute.String("event.rule", event.Rule)),
-				trace.WithAttributes(attribute.String("event.output", event.Output)),
-				trace.WithAttributes(attribute.String("event.source", event.Source)),
-				trace.WithAttributes(attribute.String("event.source", event.TraceID)),
-				trace.WithAttributes(attribute.String("rule.name", i.GetName())),
-				trace.WithAttributes(attribute.String("rule.description", i.GetDescription())),
-			)
-			span.AddEvent(event.Output, trace.EventOption(trace.WithTimestamp(event.Time)))
-			span.SetStatus(codes.Ok, "match detected")
-			span.End()
+	if len(triggeredRules) == 0 {
+		return nil
+	}
 
-			utils.PrintLog("info", log)
-			metrics.IncreaseCounter(log)
+	if !config.PrintAllEvents {
+		utils.PrintLog("info", log)
+	}
 
-			for _, a := range i.GetActions() {
-				e := new(events.Event)
-				*e = *event
-				i.AddFalcoTalonContext(e, a)
-				if ListDefaultActionners().FindActionner(a.GetActionner()).Information().UseContext &&
-					len(a.GetAdditionalContexts()) != 0 {
-					for _, j := range a.GetAdditionalContexts() {
-						elements, err := talonContext.GetContext(mctx, j, e)
-						if err != nil {
-							log := utils.LogLine{
-								Message:   "context",
-								Context:   j,
-								Rule:      e.Rule,
-								Action:    a.GetName(),
-								Actionner: a.GetActionner(),
-								TraceID:   e.TraceID,
-								Error:     err.Error(),
-							}
-							utils.PrintLog("error", log)
-							if a.IgnoreErrors != trueStr {
-								break
-							}
-						} else {
-							e.AddContext(elements)
-						}
-					}
-				}
-				err := runAction(mctx, i, a, e)
-				if err != nil && a.IgnoreErrors != trueStr {
-					break
-				}
-				if a.Continue == falseStr || a.Continue != trueStr && !ListDefaultActionners().FindActionner(a.GetActionner()).Information().Continue {
-					break
-				}
-			}
+	errs := make([]error, 0)
+	for _, i := range triggeredRules {
+		log.Message = "match"
+		log.Rule = i.GetName()
+
+		tracer := traces.GetTracer()
+		mctx, span := tracer.Start(ectx, "match",
+			trace.WithAttributes(attribute.String("event.rule", event.Rule)),
+			trace.WithAttributes(attribute.String("event.output", event.Output)),
+			trace.WithAttributes(attribute.String("event.source", event.Source)),
+			trace.WithAttributes(attribute.String("event.source", event.TraceID)),
+			trace.WithAttributes(attribute.String("rule.name", i.GetName())),
+			trace.WithAttributes(attribute.String("rule.description", i.GetDescription())),
+		)
+		span.AddEvent(event.Output, trace.EventOption(trace.WithTimestamp(event.Time)))
+		span.SetStatus(codes.Ok, "match detected")
+		span.End()
 
+		if i.IsThrottled(event) {
+			log.Status = utils.ThrottledStr
+			utils.PrintLog("info", log)
+			metrics.IncreaseCounter(log)
+			audit.RecordMatch(event, log)
+			history.RecordMatch(event, log)
+			log.Status = ""
 			if i.Continue == falseStr {
 				break
 			}
+			continue
+		}
+
+		utils.PrintLog("info", log)
+		metrics.IncreaseCounter(log)
+		audit.RecordMatch(event, log)
+		history.RecordMatch(event, log)
+
+		if err := runActions(mctx, i, event); err != nil {
+			errs = append(errs, fmt.Errorf("rule '%v': %w", i.GetName(), err))
+		}
+
+		if i.Continue == falseStr {
+			break
 		}
 	}
+	return errors.Join(errs...)
 }
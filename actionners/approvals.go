@@ -0,0 +1,169 @@
+package actionners
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/falcosecurity/falco-talon/configuration"
+	"github.com/falcosecurity/falco-talon/internal/approvals"
+	"github.com/falcosecurity/falco-talon/internal/events"
+	"github.com/falcosecurity/falco-talon/internal/leaderelection"
+	"github.com/falcosecurity/falco-talon/internal/otlp/metrics"
+	"github.com/falcosecurity/falco-talon/internal/rules"
+	"github.com/falcosecurity/falco-talon/notifiers"
+	"github.com/falcosecurity/falco-talon/utils"
+)
+
+type approvedKey struct{}
+
+// errPending is returned for an action waiting for an approval, the actions depending on it are not run
+var errPending = errors.New("the action is waiting for an approval")
+
+const expiredStr string = "expired"
+
+// parkAction stores the action as pending and notifies with the links to approve or deny it, the links
+// contain a random token specific to the pending action
+func parkAction(mctx context.Context, rule *rules.Rule, action *rules.Action, event *events.Event, log utils.LogLine) error {
+	config := configuration.GetConfiguration()
+
+	token := make([]byte, 32)
+	if _, err := rand.Read(token); err != nil {
+		log.Status = utils.FailureStr
+		log.Error = err.Error()
+		utils.PrintLog("error", log)
+		metrics.IncreaseCounter(log)
+		return err
+	}
+
+	p := &approvals.Pending{
+		ID:        uuid.NewString(),
+		Rule:      rule.GetName(),
+		Action:    action.GetName(),
+		Actionner: action.GetActionner(),
+		Event:     event,
+		CreatedAt: time.Now().UTC(),
+		ExpiresAt: time.Now().UTC().Add(time.Duration(config.Approval.ExpirationMinutes) * time.Minute),
+		Token:     hex.EncodeToString(token),
+	}
+
+	if err := approvals.Add(p); err != nil {
+		log.Status = utils.FailureStr
+		log.Error = err.Error()
+		utils.PrintLog("error", log)
+		metrics.IncreaseCounter(log)
+		return err
+	}
+
+	url := config.Approval.URL
+	if url == "" {
+		url = fmt.Sprintf("http://%v:%v", *utils.GetLocalIP(), config.ListenPort)
+	}
+	log.Status = utils.PendingStr
+	log.Result = p.ID
+	log.Output = fmt.Sprintf("approval required before %v", p.ExpiresAt.Format(time.RFC3339))
+	utils.PrintLog("info", log)
+	metrics.IncreaseCounter(log)
+	// only the notification contains the links with the token, they open a confirmation page
+	// which sends the decision with a POST request
+	log.Output += fmt.Sprintf(", approve: %v/api/v1/approvals/%v/approve?token=%v, deny: %v/api/v1/approvals/%v/deny?token=%v",
+		url, p.ID, p.Token, url, p.ID, p.Token)
+	go notifiers.Notify(mctx, rule, action, event, log)
+	return errPending
+}
+
+// withApproval returns a context in which the action is approved, the other actions still require their own approval
+func withApproval(ctx context.Context, action *rules.Action) context.Context {
+	return context.WithValue(ctx, approvedKey{}, action.GetName())
+}
+
+func isApproved(ctx context.Context, action *rules.Action) bool {
+	approved, _ := ctx.Value(approvedKey{}).(string)
+	return approved != "" && approved == action.GetName()
+}
+
+// Approve runs a pending action, and then the actions depending on it
+func Approve(id string) error {
+	p, err := approvals.Take(id)
+	if err != nil {
+		return err
+	}
+
+	rule, action := findAction(p.Rule, p.Action)
+	log := newApprovalLog(p)
+	log.Status = utils.ApprovedStr
+	if action == nil {
+		log.Status = utils.FailureStr
+		log.Error = "the rule or the action doesn't exist anymore"
+		utils.PrintLog("error", log)
+		return fmt.Errorf("the action '%v' of the rule '%v' doesn't exist anymore", p.Action, p.Rule)
+	}
+	utils.PrintLog("info", log)
+
+	ctx := withApproval(context.Background(), action)
+	go notifiers.Notify(ctx, rule, action, p.Event, log)
+	go resumeActions(ctx, rule, action, p.Event)
+	return nil
+}
+
+// Deny cancels a pending action
+func Deny(id string) error {
+	p, err := approvals.Take(id)
+	if err != nil {
+		return err
+	}
+	deny(p, "")
+	return nil
+}
+
+func deny(p *approvals.Pending, reason string) {
+	rule, action := findAction(p.Rule, p.Action)
+	log := newApprovalLog(p)
+	log.Status = utils.DeniedStr
+	log.Output = reason
+	utils.PrintLog("info", log)
+	if action != nil {
+		go notifiers.Notify(context.Background(), rule, action, p.Event, log)
+	}
+}
+
+// StartApprovalsReaper denies the pending actions once they have expired, only the leader reaps them
+func StartApprovalsReaper() {
+	for {
+		time.Sleep(10 * time.Second)
+		if !leaderelection.IsLeader() {
+			continue
+		}
+		pendings, err := approvals.List()
+		if err != nil {
+			utils.PrintLog("error", utils.LogLine{Error: err.Error(), Message: "approval"})
+			continue
+		}
+		for _, i := range pendings {
+			if time.Now().Before(i.ExpiresAt) {
+				continue
+			}
+			p, err := approvals.Take(i.ID)
+			if err != nil {
+				continue
+			}
+			deny(p, expiredStr)
+		}
+	}
+}
+
+func newApprovalLog(p *approvals.Pending) utils.LogLine {
+	return utils.LogLine{
+		Message:   "approval",
+		Rule:      p.Rule,
+		Action:    p.Action,
+		Actionner: p.Actionner,
+		Result:    p.ID,
+		TraceID:   p.Event.TraceID,
+	}
+}
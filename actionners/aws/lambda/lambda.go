@@ -104,7 +104,7 @@ func (a Actionner) Checks(_ *events.Event, action *rules.Action) error {
 	return awsChecks.CheckLambdaExist.Run(awsChecks.CheckLambdaExist{}, parameters.AWSLambdaName)
 }
 
-func (a Actionner) Run(event *events.Event, action *rules.Action) (utils.LogLine, *models.Data, error) {
+func (a Actionner) Run(ctx context.Context, event *events.Event, action *rules.Action) (utils.LogLine, *models.Data, error) {
 	lambdaClient := aws.GetLambdaClient()
 
 	var parameters Parameters
@@ -139,7 +139,7 @@ func (a Actionner) Run(event *events.Event, action *rules.Action) (utils.LogLine
 		Qualifier:      getLambdaVersion(&parameters.AWSLambdaAliasOrVersion),
 	}
 
-	lambdaOutput, err := lambdaClient.Invoke(context.Background(), input)
+	lambdaOutput, err := lambdaClient.Invoke(ctx, input)
 	if err != nil {
 		return utils.LogLine{
 			Objects: objects,
@@ -0,0 +1,631 @@
+package blocklist
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"slices"
+
+	networkingv3 "github.com/projectcalico/api/pkg/apis/projectcalico/v3"
+	errorsv1 "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+
+	calico "github.com/falcosecurity/falco-talon/internal/calico/client"
+
+	"github.com/falcosecurity/falco-talon/internal/events"
+	k8sChecks "github.com/falcosecurity/falco-talon/internal/kubernetes/checks"
+	"github.com/falcosecurity/falco-talon/internal/models"
+	"github.com/falcosecurity/falco-talon/internal/references"
+	"github.com/falcosecurity/falco-talon/internal/rules"
+	"github.com/falcosecurity/falco-talon/internal/ttl"
+	"github.com/falcosecurity/falco-talon/utils"
+)
+
+const (
+	Name          string = "blocklist"
+	Category      string = "calico"
+	Description   string = "Add the remote IP to a Calico Global Network Set, denied cluster-wide by a Calico Global Network Policy"
+	Source        string = "syscalls"
+	Continue      bool   = true
+	UseContext    bool   = false
+	AllowOutput   bool   = false
+	RequireOutput bool   = false
+	Permissions   string = `apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
+metadata:
+  name: falco-talon
+rules:
+- apiGroups:
+  - projectcalico.org
+  resources:
+  - globalnetworksets
+  - globalnetworkpolicies
+  - tier.globalnetworkpolicies
+  verbs:
+  - get
+  - update
+  - create
+  - list
+- apiGroups:
+  - projectcalico.org
+  resources:
+  - tiers
+  verbs:
+  - get
+  - create
+`
+	Example string = `- action: Block the remote IP
+  actionner: calico:blocklist
+  parameters:
+    network_set: falco-talon-blocklist
+    policy: falco-talon-blocklist
+    tier: falco-talon
+    order: 0
+    direction: both
+    ttl: 24h
+`
+)
+
+var (
+	RequiredOutputFields = []string{"fd.sip / fd.rip"}
+)
+
+type Parameters struct {
+	NetworkSet string `mapstructure:"network_set" validate:"omitempty"`
+	Policy     string `mapstructure:"policy" validate:"omitempty"`
+	Tier       string `mapstructure:"tier" validate:"omitempty"`
+	Direction  string `mapstructure:"direction" validate:"omitempty,oneof=egress ingress both"`
+	TTL        string `mapstructure:"ttl" validate:"omitempty"`
+	Order      int    `mapstructure:"order" validate:"omitempty"`
+}
+
+const (
+	defaultNameStr string = "falco-talon-blocklist"
+	defaultTierStr string = "falco-talon"
+	managedByStr   string = "app.k8s.io/managed-by"
+	// blocklistLabel is set on the globalnetworksets with the name of the set, to be selected by the rules of the policy
+	blocklistLabel string = "falco-talon.falcosecurity.org/blocklist"
+	egressStr      string = "egress"
+	ingressStr     string = "ingress"
+	bothStr        string = "both"
+	// tierOrder puts the tier of the policy before the default tier, which contains the Kubernetes network policies
+	tierOrder float64 = 100
+)
+
+// state is saved to revert the globalnetworkset, a nil expiration means the CIDR had no expiration
+type state struct {
+	Expiration *string `json:"expiration,omitempty"`
+	NetworkSet string  `json:"network_set"`
+	CIDR       string  `json:"cidr"`
+	TraceID    string  `json:"trace_id,omitempty"`
+	Added      bool    `json:"added"`
+}
+
+type Actionner struct{}
+
+func Register() *Actionner {
+	return new(Actionner)
+}
+
+func (a Actionner) Init() error {
+	return calico.Init()
+}
+
+func (a Actionner) Information() models.Information {
+	return models.Information{
+		Name:                 Name,
+		FullName:             Category + ":" + Name,
+		Category:             Category,
+		Description:          Description,
+		Source:               Source,
+		RequiredOutputFields: RequiredOutputFields,
+		Permissions:          Permissions,
+		Example:              Example,
+		Continue:             Continue,
+		AllowOutput:          AllowOutput,
+		RequireOutput:        RequireOutput,
+	}
+}
+func (a Actionner) Parameters() models.Parameters {
+	return Parameters{
+		NetworkSet: defaultNameStr,
+		Policy:     defaultNameStr,
+		Tier:       defaultTierStr,
+		Direction:  egressStr,
+		Order:      0,
+	}
+}
+
+func (a Actionner) Checks(event *events.Event, _ *rules.Action) error {
+	return k8sChecks.CheckRemoteIP(event)
+}
+
+func (a Actionner) Run(ctx context.Context, event *events.Event, action *rules.Action) (utils.LogLine, *models.Data, error) {
+	var parameters Parameters
+	err := utils.DecodeParams(action.GetParameters(), &parameters)
+	if err != nil {
+		return utils.LogLine{
+			Objects: nil,
+			Error:   err.Error(),
+			Status:  utils.FailureStr,
+		}, nil, err
+	}
+	if parameters.NetworkSet == "" {
+		parameters.NetworkSet = defaultNameStr
+	}
+	if parameters.Policy == "" {
+		parameters.Policy = defaultNameStr
+	}
+	if parameters.Tier == "" {
+		parameters.Tier = defaultTierStr
+	}
+	if parameters.Direction == "" {
+		parameters.Direction = egressStr
+	}
+
+	cidr := toCIDR(event.GetRemoteIP())
+	objects := map[string]string{
+		"ip":                  event.GetRemoteIP(),
+		"globalnetworkset":    parameters.NetworkSet,
+		"globalnetworkpolicy": policyName(&parameters),
+		"tier":                parameters.Tier,
+	}
+
+	if cidr == "" {
+		err2 := fmt.Errorf("wrong remote IP '%v'", event.GetRemoteIP())
+		return utils.LogLine{
+			Objects: objects,
+			Error:   err2.Error(),
+			Status:  utils.FailureStr,
+		}, nil, err2
+	}
+
+	previous, err := addToNetworkSet(ctx, parameters.NetworkSet, cidr, parameters.TTL, event.TraceID)
+	if err != nil {
+		return utils.LogLine{
+			Objects: objects,
+			Error:   err.Error(),
+			Status:  utils.FailureStr,
+		}, nil, err
+	}
+
+	if err := applyPolicy(ctx, &parameters); err != nil {
+		return utils.LogLine{
+			Objects: objects,
+			Error:   err.Error(),
+			Status:  utils.FailureStr,
+		}, nil, err
+	}
+
+	output := fmt.Sprintf("the IP '%v' has been added to the globalnetworkset '%v'", event.GetRemoteIP(), parameters.NetworkSet)
+	if !previous.Added {
+		output = fmt.Sprintf("the IP '%v' is already in the globalnetworkset '%v'", event.GetRemoteIP(), parameters.NetworkSet)
+	}
+	rollback, _ := json.Marshal(previous)
+
+	return utils.LogLine{
+		Objects: objects,
+		Output:  output,
+		Status:  utils.SuccessStr,
+	}, &models.Data{Rollback: rollback}, nil
+}
+
+func (a Actionner) Rollback(ctx context.Context, b []byte) (utils.LogLine, error) {
+	var previous state
+	if err := json.Unmarshal(b, &previous); err != nil {
+		return utils.LogLine{Status: utils.FailureStr, Error: err.Error()}, err
+	}
+
+	objects := map[string]string{
+		"globalnetworkset": previous.NetworkSet,
+		"cidr":             previous.CIDR,
+	}
+	calicoClient := calico.GetClient()
+
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		set, err := calicoClient.ProjectcalicoV3().GlobalNetworkSets().Get(ctx, previous.NetworkSet, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		expirations := getExpirations(set.ObjectMeta.Annotations)
+		refs := references.Get(set.ObjectMeta.Annotations)
+		_, tracked := refs[previous.CIDR]
+		switch {
+		case references.Release(refs, previous.CIDR, previous.TraceID), !tracked && previous.Added:
+			set.Spec.Nets = slices.DeleteFunc(set.Spec.Nets, func(i string) bool { return i == previous.CIDR })
+			delete(expirations, previous.CIDR)
+		case tracked:
+			// other events still rely on the CIDR, it stays with its expiration
+		case previous.Expiration != nil:
+			expirations[previous.CIDR] = *previous.Expiration
+		default:
+			delete(expirations, previous.CIDR)
+		}
+		references.Set(&set.ObjectMeta, refs)
+		setExpirations(&set.ObjectMeta, expirations)
+		_, err = calicoClient.ProjectcalicoV3().GlobalNetworkSets().Update(ctx, set, metav1.UpdateOptions{})
+		return err
+	})
+	if err != nil {
+		return utils.LogLine{
+			Objects: objects,
+			Error:   err.Error(),
+			Status:  utils.FailureStr,
+		}, err
+	}
+
+	return utils.LogLine{
+		Objects: objects,
+		Output:  fmt.Sprintf("the globalnetworkset '%v' has been restored", previous.NetworkSet),
+		Status:  utils.SuccessStr,
+	}, nil
+}
+
+// Reconcile removes the CIDRs with an expired ttl from the globalnetworksets
+func (a Actionner) Reconcile(ctx context.Context) ([]utils.LogLine, error) {
+	calicoClient := calico.GetClient()
+	opts := metav1.ListOptions{LabelSelector: ttl.Selector() + "," + blocklistLabel}
+	sets, err := calicoClient.ProjectcalicoV3().GlobalNetworkSets().List(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]utils.LogLine, 0)
+	for _, i := range sets.Items {
+		set := i
+		expired := removeExpired(&set)
+		if len(expired) == 0 {
+			continue
+		}
+		log := utils.LogLine{
+			Objects: map[string]string{"globalnetworkset": set.ObjectMeta.Name},
+		}
+		_, err := calicoClient.ProjectcalicoV3().GlobalNetworkSets().Update(ctx, &set, metav1.UpdateOptions{})
+		if err != nil {
+			log.Status = utils.FailureStr
+			log.Error = err.Error()
+		} else {
+			log.Status = utils.SuccessStr
+			log.Output = fmt.Sprintf("the CIDRs %v of the globalnetworkset '%v' have expired and have been removed", expired, set.ObjectMeta.Name)
+		}
+		results = append(results, log)
+	}
+	return results, nil
+}
+
+// addToNetworkSet adds the CIDR to the globalnetworkset, which is created if it doesn't exist yet; the CIDR isn't
+// added again if it's already covered by an entry, only its expiration is updated.
+// The event is recorded in the references of the entries added by Falco Talon, for the rollbacks.
+func addToNetworkSet(ctx context.Context, name, cidr, ttlStr, traceID string) (state, error) {
+	calicoClient := calico.GetClient()
+	var previous state
+
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		previous = state{NetworkSet: name, CIDR: cidr, TraceID: traceID}
+		set, err := calicoClient.ProjectcalicoV3().GlobalNetworkSets().Get(ctx, name, metav1.GetOptions{})
+		if err != nil && !errorsv1.IsNotFound(err) {
+			return err
+		}
+		if errorsv1.IsNotFound(err) {
+			set = &networkingv3.GlobalNetworkSet{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: name,
+				},
+			}
+		}
+		if set.ObjectMeta.Labels == nil {
+			set.ObjectMeta.Labels = make(map[string]string)
+		}
+		set.ObjectMeta.Labels[managedByStr] = utils.FalcoTalonStr
+		set.ObjectMeta.Labels[blocklistLabel] = name
+
+		nets := normalize(set.Spec.Nets)
+		entry := cidr
+		if e := covering(nets, cidr); e != "" {
+			entry = e
+		} else {
+			nets = append(nets, cidr)
+			previous.Added = true
+		}
+		set.Spec.Nets = nets
+
+		expirations := getExpirations(set.ObjectMeta.Annotations)
+		v, ok := expirations[entry]
+		if ok {
+			previous.Expiration = &v
+		}
+		previous.CIDR = entry
+		// an entry added by someone else isn't tracked, no rollback removes it
+		refs := references.Get(set.ObjectMeta.Annotations)
+		if _, tracked := refs[entry]; tracked || previous.Added {
+			references.Add(refs, entry, traceID)
+		}
+		references.Set(&set.ObjectMeta, refs)
+		// a new entry gets the ttl, an existing one keeps the latest expiration or stays permanent
+		if expiresAt, keep := ttl.Update(v, ok || previous.Added, ttlStr); keep {
+			expirations[entry] = expiresAt
+		} else {
+			delete(expirations, entry)
+		}
+		setExpirations(&set.ObjectMeta, expirations)
+
+		if set.ObjectMeta.ResourceVersion == "" {
+			_, err = calicoClient.ProjectcalicoV3().GlobalNetworkSets().Create(ctx, set, metav1.CreateOptions{})
+			if errorsv1.IsAlreadyExists(err) {
+				// another replica created it in the meantime, the next attempt updates it
+				return errorsv1.NewConflict(networkingv3.Resource("globalnetworksets"), name, err)
+			}
+			return err
+		}
+		_, err = calicoClient.ProjectcalicoV3().GlobalNetworkSets().Update(ctx, set, metav1.UpdateOptions{})
+		return err
+	})
+
+	return previous, err
+}
+
+// tier is a Calico tier, the vendored API has no type for it
+type tier struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Spec              tierSpec `json:"spec"`
+}
+
+type tierSpec struct {
+	Order *float64 `json:"order,omitempty"`
+}
+
+// tieredPolicy is a globalnetworkpolicy with its tier, the vendored API has no field for it
+type tieredPolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Spec              tieredPolicySpec `json:"spec"`
+}
+
+type tieredPolicySpec struct {
+	Tier                                 string `json:"tier"`
+	networkingv3.GlobalNetworkPolicySpec `json:",inline"`
+}
+
+// policyName returns the name of the policy, the policies of a tier other than the default one are prefixed by its name
+func policyName(parameters *Parameters) string {
+	return parameters.Tier + "." + parameters.Policy
+}
+
+// applyPolicy creates or updates the globalnetworkpolicy which denies the traffic with the CIDRs of the
+// globalnetworkset for all the endpoints of the cluster.
+// The policy is in its own tier, evaluated before the default tier with the Kubernetes network policies, and ends
+// with a Pass rule: the traffic not denied goes on with the next tier, the endpoints selected by the policy would
+// be isolated otherwise. The 'order' parameter sorts the policies of the tier.
+func applyPolicy(ctx context.Context, parameters *Parameters) error {
+	if err := createTier(ctx, parameters.Tier); err != nil {
+		return err
+	}
+
+	restClient := calico.GetClient().ProjectcalicoV3().RESTClient()
+	name := policyName(parameters)
+	spec := tieredPolicySpec{Tier: parameters.Tier, GlobalNetworkPolicySpec: createPolicySpec(parameters)}
+
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		b, err := restClient.Get().Resource("globalnetworkpolicies").Name(name).Do(ctx).Raw()
+		if errorsv1.IsNotFound(err) {
+			payload, _ := json.Marshal(tieredPolicy{
+				TypeMeta: metav1.TypeMeta{Kind: "GlobalNetworkPolicy", APIVersion: networkingv3.GroupVersionCurrent},
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   name,
+					Labels: map[string]string{managedByStr: utils.FalcoTalonStr},
+				},
+				Spec: spec,
+			})
+			err = restClient.Post().Resource("globalnetworkpolicies").Body(payload).Do(ctx).Error()
+			if errorsv1.IsAlreadyExists(err) {
+				return errorsv1.NewConflict(networkingv3.Resource("globalnetworkpolicies"), name, err)
+			}
+			return err
+		}
+		if err != nil {
+			return err
+		}
+		var policy tieredPolicy
+		if err := json.Unmarshal(b, &policy); err != nil {
+			return err
+		}
+		current, _ := json.Marshal(policy.Spec)
+		expected, _ := json.Marshal(spec)
+		if string(current) == string(expected) {
+			return nil
+		}
+		policy.Spec = spec
+		payload, _ := json.Marshal(policy)
+		return restClient.Put().Resource("globalnetworkpolicies").Name(name).Body(payload).Do(ctx).Error()
+	})
+}
+
+// createTier creates the tier of the policy, if it doesn't exist yet
+func createTier(ctx context.Context, name string) error {
+	restClient := calico.GetClient().ProjectcalicoV3().RESTClient()
+	err := restClient.Get().Resource("tiers").Name(name).Do(ctx).Error()
+	if err == nil || !errorsv1.IsNotFound(err) {
+		return err
+	}
+
+	order := tierOrder
+	payload := tier{
+		TypeMeta: metav1.TypeMeta{Kind: "Tier", APIVersion: networkingv3.GroupVersionCurrent},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: map[string]string{managedByStr: utils.FalcoTalonStr},
+		},
+	}
+	payload.Spec.Order = &order
+	b, _ := json.Marshal(payload)
+	err = restClient.Post().Resource("tiers").Body(b).Do(ctx).Error()
+	if errorsv1.IsAlreadyExists(err) {
+		return nil
+	}
+	return err
+}
+
+func createPolicySpec(parameters *Parameters) networkingv3.GlobalNetworkPolicySpec {
+	order := float64(parameters.Order)
+	selector := fmt.Sprintf("%v == '%v'", blocklistLabel, parameters.NetworkSet)
+	spec := networkingv3.GlobalNetworkPolicySpec{
+		Order:    &order,
+		Selector: "all()",
+	}
+
+	if parameters.Direction == egressStr || parameters.Direction == bothStr {
+		spec.Types = append(spec.Types, networkingv3.PolicyTypeEgress)
+		spec.Egress = []networkingv3.Rule{
+			{
+				Action:      networkingv3.Deny,
+				Destination: networkingv3.EntityRule{Selector: selector},
+			},
+			{
+				Action: networkingv3.Pass,
+			},
+		}
+	}
+	if parameters.Direction == ingressStr || parameters.Direction == bothStr {
+		spec.Types = append(spec.Types, networkingv3.PolicyTypeIngress)
+		spec.Ingress = []networkingv3.Rule{
+			{
+				Action: networkingv3.Deny,
+				Source: networkingv3.EntityRule{Selector: selector},
+			},
+			{
+				Action: networkingv3.Pass,
+			},
+		}
+	}
+
+	return spec
+}
+
+// removeExpired removes the CIDRs with an expired ttl from the globalnetworkset and returns them
+func removeExpired(set *networkingv3.GlobalNetworkSet) []string {
+	expirations := getExpirations(set.ObjectMeta.Annotations)
+	var expired []string
+	for i, j := range expirations {
+		if ttl.IsExpired(j) {
+			expired = append(expired, i)
+			delete(expirations, i)
+		}
+	}
+	if len(expired) == 0 {
+		return nil
+	}
+	slices.Sort(expired)
+	set.Spec.Nets = slices.DeleteFunc(set.Spec.Nets, func(i string) bool { return slices.Contains(expired, i) })
+	refs := references.Get(set.ObjectMeta.Annotations)
+	for _, i := range expired {
+		delete(refs, i)
+	}
+	references.Set(&set.ObjectMeta, refs)
+	setExpirations(&set.ObjectMeta, expirations)
+	return expired
+}
+
+func getExpirations(annotations map[string]string) map[string]string {
+	expirations := make(map[string]string)
+	if v, ok := annotations[ttl.BlocklistAnnotation]; ok {
+		_ = json.Unmarshal([]byte(v), &expirations)
+	}
+	return expirations
+}
+
+// setExpirations sets the annotation with the expirations of the CIDRs, and the marker label
+func setExpirations(meta *metav1.ObjectMeta, expirations map[string]string) {
+	if meta.Annotations == nil {
+		meta.Annotations = make(map[string]string)
+	}
+	if len(expirations) == 0 {
+		delete(meta.Annotations, ttl.BlocklistAnnotation)
+	} else {
+		b, _ := json.Marshal(expirations)
+		meta.Annotations[ttl.BlocklistAnnotation] = string(b)
+	}
+	if meta.Labels == nil {
+		meta.Labels = make(map[string]string)
+	}
+	if v := ttl.MarkerValue(meta.Annotations); v != nil {
+		meta.Labels[ttl.Label] = *v
+	} else {
+		delete(meta.Labels, ttl.Label)
+	}
+}
+
+// toCIDR returns the CIDR of a single IP, with a /32 or a /128 mask
+func toCIDR(ip string) string {
+	i := net.ParseIP(ip)
+	if i == nil {
+		return ""
+	}
+	if i.To4() != nil {
+		return i.String() + "/32"
+	}
+	return i.String() + "/128"
+}
+
+// normalize returns the CIDRs in their canonical form, without the duplicates, the single IPs get a mask
+func normalize(nets []string) []string {
+	result := make([]string, 0, len(nets))
+	for _, i := range nets {
+		if _, n, err := net.ParseCIDR(i); err == nil {
+			result = append(result, n.String())
+			continue
+		}
+		if c := toCIDR(i); c != "" {
+			result = append(result, c)
+			continue
+		}
+		result = append(result, i)
+	}
+	return utils.Deduplicate(result)
+}
+
+// covering returns the entry of the nets which contains the CIDR of a single IP, if any
+func covering(nets []string, cidr string) string {
+	ip, _, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return ""
+	}
+	for _, i := range nets {
+		if i == cidr {
+			return i
+		}
+	}
+	for _, i := range nets {
+		if _, n, err := net.ParseCIDR(i); err == nil && n.Contains(ip) {
+			return i
+		}
+	}
+	return ""
+}
+
+func (a Actionner) CheckParameters(action *rules.Action) error {
+	var parameters Parameters
+
+	err := utils.DecodeParams(action.GetParameters(), &parameters)
+	if err != nil {
+		return err
+	}
+
+	if err := ttl.Check(parameters.TTL); err != nil {
+		return err
+	}
+
+	if parameters.Tier == "default" {
+		return errors.New("the policy can't be in the default tier, it would skip the Kubernetes network policies")
+	}
+
+	err = utils.ValidateStruct(parameters)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
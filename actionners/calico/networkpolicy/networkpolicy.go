@@ -2,6 +2,7 @@ package networkpolicy
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"net"
 	"strings"
@@ -17,6 +18,7 @@ import (
 	k8s "github.com/falcosecurity/falco-talon/internal/kubernetes/client"
 	"github.com/falcosecurity/falco-talon/internal/models"
 	"github.com/falcosecurity/falco-talon/internal/rules"
+	"github.com/falcosecurity/falco-talon/internal/ttl"
 	"github.com/falcosecurity/falco-talon/utils"
 )
 
@@ -50,6 +52,8 @@ rules:
   - update
   - patch
   - create
+  - delete
+  - list
 - apiGroups:
   - apps
   resources:
@@ -85,6 +89,7 @@ rules:
     allow_namespaces:
       - "green-ns"
       - "blue-ns"
+    ttl: 1h
 `
 )
 
@@ -96,11 +101,19 @@ type Parameters struct {
 	AllowCIDR       []string `mapstructure:"allow_cidr" validate:"omitempty"`
 	AllowNamespaces []string `mapstructure:"allow_namespaces" validate:"omitempty"`
 	Order           int      `mapstructure:"order" validate:"omitempty"`
+	TTL             string   `mapstructure:"ttl" validate:"omitempty"`
 }
 
 const mask32 string = "/32"
 const managedByStr string = "app.k8s.io/managed-by"
 
+// state is saved to revert the networkpolicy, a nil spec means it has been created
+type state struct {
+	Spec      *networkingv3.NetworkPolicySpec `json:"spec,omitempty"`
+	Name      string                          `json:"name"`
+	Namespace string                          `json:"namespace"`
+}
+
 type Actionner struct{}
 
 func Register() *Actionner {
@@ -145,7 +158,7 @@ func (a Actionner) Checks(event *events.Event, _ *rules.Action) error {
 	return nil
 }
 
-func (a Actionner) Run(event *events.Event, action *rules.Action) (utils.LogLine, *models.Data, error) {
+func (a Actionner) Run(ctx context.Context, event *events.Event, action *rules.Action) (utils.LogLine, *models.Data, error) {
 	podName := event.GetPodName()
 	namespace := event.GetNamespaceName()
 
@@ -166,7 +179,7 @@ func (a Actionner) Run(event *events.Event, action *rules.Action) (utils.LogLine
 	k8sClient := k8s.GetClient()
 	calicoClient := calico.GetClient()
 
-	pod, err := k8sClient.GetPod(podName, namespace)
+	pod, err := k8sClient.GetPod(ctx, podName, namespace)
 	if err != nil {
 		return utils.LogLine{
 			Objects: objects,
@@ -181,7 +194,7 @@ func (a Actionner) Run(event *events.Event, action *rules.Action) (utils.LogLine
 	if len(pod.OwnerReferences) != 0 {
 		switch pod.OwnerReferences[0].Kind {
 		case "DaemonSet":
-			u, err2 := k8sClient.GetDaemonsetFromPod(pod)
+			u, err2 := k8sClient.GetDaemonsetFromPod(ctx, pod)
 			if err2 != nil {
 				return utils.LogLine{
 					Objects: objects,
@@ -192,7 +205,7 @@ func (a Actionner) Run(event *events.Event, action *rules.Action) (utils.LogLine
 			owner = u.ObjectMeta.Name
 			labels = u.Spec.Selector.MatchLabels
 		case "StatefulSet":
-			u, err2 := k8sClient.GetStatefulsetFromPod(pod)
+			u, err2 := k8sClient.GetStatefulsetFromPod(ctx, pod)
 			if err2 != nil {
 				return utils.LogLine{
 					Objects: objects,
@@ -203,7 +216,7 @@ func (a Actionner) Run(event *events.Event, action *rules.Action) (utils.LogLine
 			owner = u.ObjectMeta.Name
 			labels = u.Spec.Selector.MatchLabels
 		case "ReplicaSet":
-			u, err2 := k8sClient.GetReplicasetFromPod(pod)
+			u, err2 := k8sClient.GetReplicasetFromPod(ctx, pod)
 			if err2 != nil {
 				return utils.LogLine{
 					Objects: objects,
@@ -283,7 +296,7 @@ func (a Actionner) Run(event *events.Event, action *rules.Action) (utils.LogLine
 
 	var output string
 	var netpol *networkingv3.NetworkPolicy
-	netpol, err = calicoClient.ProjectcalicoV3().NetworkPolicies(namespace).Get(context.Background(), owner, metav1.GetOptions{})
+	netpol, err = calicoClient.ProjectcalicoV3().NetworkPolicies(namespace).Get(ctx, owner, metav1.GetOptions{})
 	if errorsv1.IsNotFound(err) {
 		payload.Spec.Egress = []networkingv3.Rule{*denyRule}
 		if allowCIDRRule != nil {
@@ -292,7 +305,8 @@ func (a Actionner) Run(event *events.Event, action *rules.Action) (utils.LogLine
 		if allowNamespacesRule != nil {
 			payload.Spec.Egress = append(payload.Spec.Egress, *allowNamespacesRule)
 		}
-		_, err2 := calicoClient.ProjectcalicoV3().NetworkPolicies(namespace).Create(context.Background(), &payload, metav1.CreateOptions{})
+		payload.ObjectMeta.Annotations = ttl.SetExpiration(payload.ObjectMeta.Labels, nil, true, parameters.TTL)
+		_, err2 := calicoClient.ProjectcalicoV3().NetworkPolicies(namespace).Create(ctx, &payload, metav1.CreateOptions{})
 		if err2 != nil {
 			if !errorsv1.IsAlreadyExists(err2) {
 				return utils.LogLine{
@@ -301,14 +315,15 @@ func (a Actionner) Run(event *events.Event, action *rules.Action) (utils.LogLine
 					Status:  utils.FailureStr,
 				}, nil, err2
 			}
-			netpol, err = calicoClient.ProjectcalicoV3().NetworkPolicies(namespace).Get(context.Background(), owner, metav1.GetOptions{})
+			netpol, err = calicoClient.ProjectcalicoV3().NetworkPolicies(namespace).Get(ctx, owner, metav1.GetOptions{})
 		} else {
 			output = fmt.Sprintf("the caliconetworkpolicy '%v' in the namespace '%v' has been created", owner, namespace)
+			rollback, _ := json.Marshal(state{Name: owner, Namespace: namespace})
 			return utils.LogLine{
 				Objects: objects,
 				Output:  output,
 				Status:  utils.SuccessStr,
-			}, nil, nil
+			}, &models.Data{Rollback: rollback}, nil
 		}
 	}
 	if err != nil {
@@ -319,6 +334,8 @@ func (a Actionner) Run(event *events.Event, action *rules.Action) (utils.LogLine
 		}, nil, err
 	}
 	payload.ObjectMeta.ResourceVersion = netpol.ObjectMeta.ResourceVersion
+	payload.ObjectMeta.Annotations = ttl.SetExpiration(payload.ObjectMeta.Labels, netpol.ObjectMeta.Annotations, false, parameters.TTL)
+	rollback, _ := json.Marshal(state{Spec: &netpol.Spec, Name: owner, Namespace: namespace})
 	var denyCIDR []string
 	for _, i := range netpol.Spec.Egress {
 		if i.Action == "Deny" {
@@ -335,7 +352,7 @@ func (a Actionner) Run(event *events.Event, action *rules.Action) (utils.LogLine
 	if allowNamespacesRule != nil {
 		payload.Spec.Egress = append(payload.Spec.Egress, *allowNamespacesRule)
 	}
-	_, err = calicoClient.ProjectcalicoV3().NetworkPolicies(namespace).Update(context.Background(), &payload, metav1.UpdateOptions{})
+	_, err = calicoClient.ProjectcalicoV3().NetworkPolicies(namespace).Update(ctx, &payload, metav1.UpdateOptions{})
 	if err != nil {
 		return utils.LogLine{
 			Objects: objects,
@@ -350,7 +367,90 @@ func (a Actionner) Run(event *events.Event, action *rules.Action) (utils.LogLine
 		Objects: objects,
 		Output:  output,
 		Status:  utils.SuccessStr,
-	}, nil, nil
+	}, &models.Data{Rollback: rollback}, nil
+}
+
+func (a Actionner) Rollback(ctx context.Context, b []byte) (utils.LogLine, error) {
+	var previous state
+	if err := json.Unmarshal(b, &previous); err != nil {
+		return utils.LogLine{Status: utils.FailureStr, Error: err.Error()}, err
+	}
+
+	objects := map[string]string{
+		"caliconetworkpolicy": previous.Name,
+		"namespace":           previous.Namespace,
+	}
+	calicoClient := calico.GetClient()
+
+	var output string
+	if previous.Spec == nil {
+		err := calicoClient.ProjectcalicoV3().NetworkPolicies(previous.Namespace).Delete(ctx, previous.Name, metav1.DeleteOptions{})
+		if err != nil && !errorsv1.IsNotFound(err) {
+			return utils.LogLine{
+				Objects: objects,
+				Error:   err.Error(),
+				Status:  utils.FailureStr,
+			}, err
+		}
+		output = fmt.Sprintf("the caliconetworkpolicy '%v' in the namespace '%v' has been deleted", previous.Name, previous.Namespace)
+	} else {
+		netpol, err := calicoClient.ProjectcalicoV3().NetworkPolicies(previous.Namespace).Get(ctx, previous.Name, metav1.GetOptions{})
+		if err != nil {
+			return utils.LogLine{
+				Objects: objects,
+				Error:   err.Error(),
+				Status:  utils.FailureStr,
+			}, err
+		}
+		netpol.Spec = *previous.Spec
+		_, err = calicoClient.ProjectcalicoV3().NetworkPolicies(previous.Namespace).Update(ctx, netpol, metav1.UpdateOptions{})
+		if err != nil {
+			return utils.LogLine{
+				Objects: objects,
+				Error:   err.Error(),
+				Status:  utils.FailureStr,
+			}, err
+		}
+		output = fmt.Sprintf("the caliconetworkpolicy '%v' in the namespace '%v' has been restored", previous.Name, previous.Namespace)
+	}
+
+	return utils.LogLine{
+		Objects: objects,
+		Output:  output,
+		Status:  utils.SuccessStr,
+	}, nil
+}
+
+// Reconcile deletes the caliconetworkpolicies with an expired ttl
+func (a Actionner) Reconcile(ctx context.Context) ([]utils.LogLine, error) {
+	calicoClient := calico.GetClient()
+	netpols, err := calicoClient.ProjectcalicoV3().NetworkPolicies("").List(ctx, metav1.ListOptions{LabelSelector: ttl.Selector()})
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]utils.LogLine, 0)
+	for _, i := range netpols.Items {
+		if !ttl.IsExpired(i.ObjectMeta.Annotations[ttl.ExpiresAtAnnotation]) {
+			continue
+		}
+		log := utils.LogLine{
+			Objects: map[string]string{
+				"caliconetworkpolicy": i.ObjectMeta.Name,
+				"namespace":           i.ObjectMeta.Namespace,
+			},
+		}
+		err := calicoClient.ProjectcalicoV3().NetworkPolicies(i.ObjectMeta.Namespace).Delete(ctx, i.ObjectMeta.Name, metav1.DeleteOptions{})
+		if err != nil && !errorsv1.IsNotFound(err) {
+			log.Status = utils.FailureStr
+			log.Error = err.Error()
+		} else {
+			log.Status = utils.SuccessStr
+			log.Output = fmt.Sprintf("the caliconetworkpolicy '%v' in the namespace '%v' has expired and has been deleted", i.ObjectMeta.Name, i.ObjectMeta.Namespace)
+		}
+		results = append(results, log)
+	}
+	return results, nil
 }
 
 func createAllowCIDREgressRule(parameters *Parameters) *networkingv3.Rule {
@@ -415,6 +515,10 @@ func (a Actionner) CheckParameters(action *rules.Action) error {
 		}
 	}
 
+	if err := ttl.Check(parameters.TTL); err != nil {
+		return err
+	}
+
 	err = utils.ValidateStruct(parameters)
 	if err != nil {
 		return err
@@ -2,29 +2,40 @@ package networkpolicy
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"maps"
 	"net"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
 
 	v2 "github.com/cilium/cilium/pkg/k8s/apis/cilium.io/v2"
 	v1 "github.com/cilium/cilium/pkg/k8s/slim/k8s/apis/meta/v1"
 	"github.com/cilium/cilium/pkg/policy/api"
 	errorsv1 "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
 
 	cilium "github.com/falcosecurity/falco-talon/internal/cilium/client"
 	"github.com/falcosecurity/falco-talon/internal/models"
+	"github.com/falcosecurity/falco-talon/internal/references"
 
 	"github.com/falcosecurity/falco-talon/internal/events"
 	k8sChecks "github.com/falcosecurity/falco-talon/internal/kubernetes/checks"
 	k8s "github.com/falcosecurity/falco-talon/internal/kubernetes/client"
 	"github.com/falcosecurity/falco-talon/internal/rules"
+	"github.com/falcosecurity/falco-talon/internal/ttl"
 	"github.com/falcosecurity/falco-talon/utils"
 )
 
 const (
 	Name          string = "networkpolicy"
 	Category      string = "cilium"
-	Description   string = "Create a Cilium Network Policy to block the egress traffic to a specific IP"
+	Description   string = "Create a Cilium Network Policy to block the egress traffic to a specific IP or domain, with FQDN and HTTP rules"
 	Source        string = "syscalls"
 	Continue      bool   = true
 	UseContext    bool   = false
@@ -51,6 +62,8 @@ rules:
   - update
   - patch
   - create
+  - delete
+  - list
 - apiGroups:
   - apps
   resources:
@@ -77,14 +90,25 @@ rules:
   - get
 `
 	Example string = `- action: Create Cilium netpol
-actionner: cilium:networkpolicy
-parameters:
-  allow_cidr:
-	- "192.168.1.0/24"
-	- "172.17.0.0/16"
-  allow_namespaces:
-	- "green-ns"
-	- "blue-ns"
+  actionner: cilium:networkpolicy
+  parameters:
+    allow_cidr:
+      - "192.168.1.0/24"
+      - "172.17.0.0/16"
+    allow_namespaces:
+      - "green-ns"
+      - "blue-ns"
+    allow_fqdns:
+      - "api.example.com"
+      - "*.falco.org"
+    allow_http:
+      - method: GET
+        path: "/api/.*"
+    http_ports:
+      - 80
+      - 8080
+    deny_remote_domain: true
+    ttl: 1h
 `
 )
 
@@ -93,17 +117,47 @@ var (
 )
 
 type Parameters struct {
-	AllowCIDR       []string `mapstructure:"allow_cidr" validate:"omitempty"`
-	AllowNamespaces []string `mapstructure:"allow_namespaces" validate:"omitempty"`
+	AllowCIDR        []string   `mapstructure:"allow_cidr" validate:"omitempty"`
+	AllowNamespaces  []string   `mapstructure:"allow_namespaces" validate:"omitempty"`
+	AllowFQDNs       []string   `mapstructure:"allow_fqdns" validate:"omitempty"`
+	AllowHTTP        []HTTPRule `mapstructure:"allow_http" validate:"omitempty,dive"`
+	HTTPPorts        []int      `mapstructure:"http_ports" validate:"omitempty,dive,min=1,max=65535"`
+	TTL              string     `mapstructure:"ttl" validate:"omitempty"`
+	DenyRemoteDomain bool       `mapstructure:"deny_remote_domain" validate:"omitempty"`
+}
+
+// HTTPRule is a HTTP request allowed by the policy, the method and the path are regular expressions
+type HTTPRule struct {
+	Method string `mapstructure:"method" validate:"omitempty"`
+	Path   string `mapstructure:"path" validate:"omitempty"`
 }
 
 const (
 	mask32            string = "/32"
+	mask128           string = "/128"
+	anyIPv4           string = "0.0.0.0/0"
+	defaultHTTPPort   int    = 80
+	dnsPort           string = "53"
 	managedByStr      string = "app.k8s.io/managed-by"
 	netpolDescription string = "Network policy created by Falco Talon"
-	namespaceKey             = "k8s.io/metadata.name"
+	// cidrRefPrefix is the prefix of the entries of the references for the denied CIDRs, ruleRefPrefix the one
+	// for the allow rules
+	cidrRefPrefix   string = "cidr:"
+	ruleRefPrefix   string = "rule:"
+	namespaceKey           = "k8s.io/metadata.name"
+	podNamespaceKey        = "k8s:io.kubernetes.pod.namespace"
 )
 
+// state is saved to revert the networkpolicy, with the denied CIDRs and the allow rules the action relies on
+type state struct {
+	Name      string           `json:"name"`
+	Namespace string           `json:"namespace"`
+	TraceID   string           `json:"trace_id"`
+	Denied    []string         `json:"denied,omitempty"`
+	Allowed   []api.EgressRule `json:"allowed,omitempty"`
+	Created   bool             `json:"created"`
+}
+
 type Actionner struct{}
 
 func Register() *Actionner {
@@ -131,16 +185,31 @@ func (a Actionner) Information() models.Information {
 }
 func (a Actionner) Parameters() models.Parameters {
 	return Parameters{
-		AllowCIDR:       []string{"0.0.0.0/0"},
-		AllowNamespaces: []string{},
+		AllowCIDR:        []string{anyIPv4},
+		AllowNamespaces:  []string{},
+		AllowFQDNs:       []string{},
+		AllowHTTP:        []HTTPRule{},
+		HTTPPorts:        []int{defaultHTTPPort},
+		DenyRemoteDomain: false,
 	}
 }
 
-func (a Actionner) Checks(event *events.Event, _ *rules.Action) error {
-	return k8sChecks.CheckPodExist(event)
+func (a Actionner) Checks(event *events.Event, action *rules.Action) error {
+	if err := k8sChecks.CheckPodExist(event); err != nil {
+		return err
+	}
+
+	var parameters Parameters
+	if err := utils.DecodeParams(action.GetParameters(), &parameters); err != nil {
+		return err
+	}
+	if parameters.DenyRemoteDomain {
+		return k8sChecks.CheckRemoteName(event)
+	}
+	return nil
 }
 
-func (a Actionner) Run(event *events.Event, action *rules.Action) (utils.LogLine, *models.Data, error) {
+func (a Actionner) Run(ctx context.Context, event *events.Event, action *rules.Action) (utils.LogLine, *models.Data, error) {
 	podName := event.GetPodName()
 	namespace := event.GetNamespaceName()
 
@@ -164,7 +233,7 @@ func (a Actionner) Run(event *events.Event, action *rules.Action) (utils.LogLine
 	k8sClient := k8s.GetClient()
 	ciliumClient := cilium.GetClient()
 
-	pod, err := k8sClient.GetPod(podName, namespace)
+	pod, err := k8sClient.GetPod(ctx, podName, namespace)
 	if err != nil {
 		return utils.LogLine{
 				Objects: objects,
@@ -181,7 +250,7 @@ func (a Actionner) Run(event *events.Event, action *rules.Action) (utils.LogLine
 	if len(pod.OwnerReferences) != 0 {
 		switch pod.OwnerReferences[0].Kind {
 		case "DaemonSet":
-			u, err2 := k8sClient.GetDaemonsetFromPod(pod)
+			u, err2 := k8sClient.GetDaemonsetFromPod(ctx, pod)
 			if err2 != nil {
 				return utils.LogLine{
 						Objects: objects,
@@ -194,7 +263,7 @@ func (a Actionner) Run(event *events.Event, action *rules.Action) (utils.LogLine
 			owner = u.ObjectMeta.Name
 			labels = u.Spec.Selector.MatchLabels
 		case "StatefulSet":
-			u, err2 := k8sClient.GetStatefulsetFromPod(pod)
+			u, err2 := k8sClient.GetStatefulsetFromPod(ctx, pod)
 			if err2 != nil {
 				return utils.LogLine{
 						Objects: objects,
@@ -207,7 +276,7 @@ func (a Actionner) Run(event *events.Event, action *rules.Action) (utils.LogLine
 			owner = u.ObjectMeta.Name
 			labels = u.Spec.Selector.MatchLabels
 		case "ReplicaSet":
-			u, err2 := k8sClient.GetReplicasetFromPod(pod)
+			u, err2 := k8sClient.GetReplicasetFromPod(ctx, pod)
 			if err2 != nil {
 				return utils.LogLine{
 						Objects: objects,
@@ -263,21 +332,32 @@ func (a Actionner) Run(event *events.Event, action *rules.Action) (utils.LogLine
 		LabelSelector: &v1.LabelSelector{MatchLabels: labels},
 	}
 
-	var allowCIDRRule, allowNamespacesRule *api.EgressRule
+	allowRules := createAllowEgressRules(parameters)
 
-	if parameters.AllowCIDR == nil && parameters.AllowNamespaces == nil {
-		allowCIDRRule = &api.EgressRule{
-			EgressCommonRule: api.EgressCommonRule{
-				ToCIDR: api.CIDRSlice{"0.0.0.0/0"},
-			},
+	denied := make([]string, 0)
+	if ip := event.GetRemoteIP(); ip != "" {
+		denied = append(denied, ip)
+	}
+	if parameters.DenyRemoteDomain {
+		// the deny rules of Cilium don't support the FQDNs, the IPs of the domain are denied instead
+		domain := event.GetRemoteName()
+		objects["domain"] = domain
+		ips, err2 := net.DefaultResolver.LookupIP(ctx, "ip", domain)
+		if err2 != nil {
+			err2 = fmt.Errorf("can't resolve the domain '%v': %v", domain, err2)
+			return utils.LogLine{
+					Objects: objects,
+					Error:   err2.Error(),
+					Status:  utils.FailureStr,
+				},
+				nil,
+				err2
+		}
+		for _, i := range ips {
+			denied = append(denied, i.String())
 		}
-	} else {
-		allowCIDRRule = createAllowCIDREgressRule(parameters)
-		allowNamespacesRule = createAllowNamespaceEgressRule(parameters)
 	}
-
-	denyRule := createDenyEgressRule([]string{event.GetRemoteIP() + mask32})
-	if denyRule == nil {
+	if len(denied) == 0 {
 		err2 := fmt.Errorf("can't create deny rule for the networkpolicy '%v' in the namespace '%v'", owner, namespace)
 		return utils.LogLine{
 				Objects: objects,
@@ -287,20 +367,25 @@ func (a Actionner) Run(event *events.Event, action *rules.Action) (utils.LogLine
 			nil,
 			err2
 	}
+	denyRule := createDenyEgressRule(utils.Deduplicate(denied))
 
 	var output string
 	var netpol *v2.CiliumNetworkPolicy
 
-	netpol, err = ciliumClient.CiliumV2().CiliumNetworkPolicies(namespace).Get(context.Background(), owner, metav1.GetOptions{})
+	objects["ciliumnetworkpolicy"] = owner
+
+	previous := state{Name: owner, Namespace: namespace, TraceID: event.TraceID}
+
+	netpol, err = ciliumClient.CiliumV2().CiliumNetworkPolicies(namespace).Get(ctx, owner, metav1.GetOptions{})
 	if errorsv1.IsNotFound(err) {
 		payload.Spec.EgressDeny = []api.EgressDenyRule{*denyRule}
-		if allowCIDRRule != nil {
-			payload.Spec.Egress = append(payload.Spec.Egress, *allowCIDRRule)
-		}
-		if allowNamespacesRule != nil {
-			payload.Spec.Egress = append(payload.Spec.Egress, *allowNamespacesRule)
-		}
-		_, err2 := ciliumClient.CiliumV2().CiliumNetworkPolicies(namespace).Create(context.Background(), &payload, metav1.CreateOptions{})
+		payload.Spec.Egress = allowRules
+		payload.ObjectMeta.Annotations = ttl.SetExpiration(payload.ObjectMeta.Labels, nil, true, parameters.TTL)
+		previous.Created = true
+		refs := make(map[string][]string)
+		track(refs, &previous, nil, payload.Spec)
+		references.Set(&payload.ObjectMeta, refs)
+		_, err2 := ciliumClient.CiliumV2().CiliumNetworkPolicies(namespace).Create(ctx, &payload, metav1.CreateOptions{})
 		if err2 != nil {
 			return utils.LogLine{
 					Objects: objects,
@@ -311,12 +396,13 @@ func (a Actionner) Run(event *events.Event, action *rules.Action) (utils.LogLine
 				err2
 		}
 		output = fmt.Sprintf("the ciliumnetworkpolicy '%v' in the namespace '%v' has been created", owner, namespace)
+		rollback, _ := json.Marshal(previous)
 		return utils.LogLine{
 				Objects: objects,
 				Output:  output,
 				Status:  utils.SuccessStr,
 			},
-			nil,
+			&models.Data{Rollback: rollback},
 			nil
 	}
 	if err != nil {
@@ -330,27 +416,58 @@ func (a Actionner) Run(event *events.Event, action *rules.Action) (utils.LogLine
 	}
 
 	payload.ObjectMeta.ResourceVersion = netpol.ObjectMeta.ResourceVersion
-	payload.Spec.Egress = netpol.Spec.Egress
-	payload.Spec.EgressDeny = netpol.Spec.EgressDeny
-
-	denyRule = createDenyEgressRule([]string{event.GetRemoteIP() + mask32})
-
-	for i := range payload.Spec.EgressDeny {
-		if !denyEgressRuleExists(&payload.Spec.EgressDeny[i], denyRule) {
-			payload.Spec.EgressDeny = append(payload.Spec.EgressDeny, *denyRule)
+	payload.ObjectMeta.Annotations = ttl.SetExpiration(payload.ObjectMeta.Labels, netpol.ObjectMeta.Annotations, false, parameters.TTL)
+
+	// the rules are merged with the existing ones, the rules already present are not added again; they're
+	// copied, the existing policy is kept as is for the comparison
+	if netpol.Spec != nil {
+		current := netpol.Spec.DeepCopy()
+		payload.Spec.Egress = current.Egress
+		payload.Spec.EgressDeny = current.EgressDeny
+	}
+	payload.Spec.EgressDeny = mergeDenyEgressRule(payload.Spec.EgressDeny, denyRule)
+	for i := range allowRules {
+		if !slices.ContainsFunc(payload.Spec.Egress, func(r api.EgressRule) bool { return egressRuleExists(&r, &allowRules[i]) }) {
+			payload.Spec.Egress = append(payload.Spec.Egress, allowRules[i])
 		}
 	}
-
-	for i := range payload.Spec.Egress {
-		if !egressRuleExists(&payload.Spec.Egress[i], allowCIDRRule) && allowCIDRRule != nil {
-			payload.Spec.Egress = append(payload.Spec.Egress, *allowCIDRRule)
+	refs := references.Get(netpol.ObjectMeta.Annotations)
+	track(refs, &previous, netpol.Spec, payload.Spec)
+	references.Set(&payload.ObjectMeta, refs)
+	rollback, _ := json.Marshal(previous)
+
+	// only the rules are compared, the selectors differ even for a same policy; the ttl and the references are
+	// still updated
+	if netpol.Spec != nil && sameEgressRules(netpol.Spec, payload.Spec) {
+		output = fmt.Sprintf("the ciliumnetworkpolicy '%v' in the namespace '%v' is already up to date", owner, namespace)
+		if !maps.Equal(netpol.ObjectMeta.Annotations, payload.ObjectMeta.Annotations) || !maps.Equal(netpol.ObjectMeta.Labels, payload.ObjectMeta.Labels) {
+			netpol.ObjectMeta.Annotations = payload.ObjectMeta.Annotations
+			netpol.ObjectMeta.Labels = payload.ObjectMeta.Labels
+			if _, err = ciliumClient.CiliumV2().CiliumNetworkPolicies(namespace).Update(ctx, netpol, metav1.UpdateOptions{}); err != nil {
+				return utils.LogLine{
+						Objects: objects,
+						Error:   err.Error(),
+						Status:  utils.FailureStr,
+					},
+					nil,
+					err
+			}
+			output += ", its ttl has been updated"
 		}
-		if !egressRuleExists(&payload.Spec.Egress[i], allowNamespacesRule) && allowNamespacesRule != nil {
-			payload.Spec.Egress = append(payload.Spec.Egress, *allowNamespacesRule)
+		var data *models.Data
+		if len(previous.Denied) != 0 || len(previous.Allowed) != 0 {
+			data = &models.Data{Rollback: rollback}
 		}
+		return utils.LogLine{
+				Objects: objects,
+				Output:  output,
+				Status:  utils.SuccessStr,
+			},
+			data,
+			nil
 	}
 
-	_, err = ciliumClient.CiliumV2().CiliumNetworkPolicies(namespace).Update(context.Background(), &payload, metav1.UpdateOptions{})
+	_, err = ciliumClient.CiliumV2().CiliumNetworkPolicies(namespace).Update(ctx, &payload, metav1.UpdateOptions{})
 	if err != nil {
 		return utils.LogLine{
 				Objects: objects,
@@ -361,19 +478,195 @@ func (a Actionner) Run(event *events.Event, action *rules.Action) (utils.LogLine
 			err
 	}
 	output = fmt.Sprintf("the ciliumnetworkpolicy '%v' in the namespace '%v' has been updated", owner, namespace)
-	objects["NetworkPolicy"] = owner
 
 	return utils.LogLine{
 			Objects: objects,
 			Output:  output,
 			Status:  utils.SuccessStr,
 		},
-		nil,
+		&models.Data{Rollback: rollback},
 		nil
 }
 
+func (a Actionner) Rollback(ctx context.Context, b []byte) (utils.LogLine, error) {
+	var previous state
+	if err := json.Unmarshal(b, &previous); err != nil {
+		return utils.LogLine{Status: utils.FailureStr, Error: err.Error()}, err
+	}
+
+	objects := map[string]string{
+		"ciliumnetworkpolicy": previous.Name,
+		"namespace":           previous.Namespace,
+	}
+	netpols := cilium.GetClient().CiliumV2().CiliumNetworkPolicies(previous.Namespace)
+
+	var output string
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		netpol, err := netpols.Get(ctx, previous.Name, metav1.GetOptions{})
+		if errorsv1.IsNotFound(err) {
+			output = fmt.Sprintf("the ciliumnetworkpolicy '%v' in the namespace '%v' doesn't exist anymore", previous.Name, previous.Namespace)
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		refs := references.Get(netpol.ObjectMeta.Annotations)
+		spec := &api.Rule{}
+		if netpol.Spec != nil {
+			spec = netpol.Spec.DeepCopy()
+		}
+		revert(spec, &previous, refs)
+		if previous.Created && len(spec.Egress) == 0 && len(spec.EgressDeny) == 0 {
+			// the policy has been created by the action and nothing else relies on it
+			opts := metav1.DeleteOptions{Preconditions: &metav1.Preconditions{ResourceVersion: &netpol.ObjectMeta.ResourceVersion}}
+			if err := netpols.Delete(ctx, previous.Name, opts); err != nil && !errorsv1.IsNotFound(err) {
+				return err
+			}
+			output = fmt.Sprintf("the ciliumnetworkpolicy '%v' in the namespace '%v' has been deleted", previous.Name, previous.Namespace)
+			return nil
+		}
+		netpol.Spec = spec
+		references.Set(&netpol.ObjectMeta, refs)
+		if _, err := netpols.Update(ctx, netpol, metav1.UpdateOptions{}); err != nil {
+			return err
+		}
+		output = fmt.Sprintf("the ciliumnetworkpolicy '%v' in the namespace '%v' has been restored", previous.Name, previous.Namespace)
+		return nil
+	})
+	if err != nil {
+		return utils.LogLine{
+			Objects: objects,
+			Error:   err.Error(),
+			Status:  utils.FailureStr,
+		}, err
+	}
+
+	return utils.LogLine{
+		Objects: objects,
+		Output:  output,
+		Status:  utils.SuccessStr,
+	}, nil
+}
+
+// revert removes from the spec the denied CIDRs and the allow rules of the action no other event relies on, the
+// deny rules left without CIDR are removed
+func revert(spec *api.Rule, previous *state, refs map[string][]string) {
+	for _, i := range previous.Denied {
+		if !references.Release(refs, cidrRefPrefix+i, previous.TraceID) {
+			continue
+		}
+		for j := range spec.EgressDeny {
+			spec.EgressDeny[j].ToCIDR = slices.DeleteFunc(spec.EgressDeny[j].ToCIDR, func(c api.CIDR) bool { return string(c) == i })
+		}
+		spec.EgressDeny = slices.DeleteFunc(spec.EgressDeny, func(r api.EgressDenyRule) bool {
+			return len(r.ToCIDR) == 0 && len(r.ToCIDRSet) == 0 && len(r.ToEndpoints) == 0 && len(r.ToEntities) == 0 && len(r.ToPorts) == 0
+		})
+	}
+	for i := range previous.Allowed {
+		if !references.Release(refs, ruleRef(&previous.Allowed[i]), previous.TraceID) {
+			continue
+		}
+		spec.Egress = slices.DeleteFunc(spec.Egress, func(r api.EgressRule) bool { return egressRuleExists(&r, &previous.Allowed[i]) })
+	}
+}
+
+// track records the event in the references of the denied CIDRs and the allow rules of the policy it relies on;
+// the ones set before by someone else aren't tracked
+func track(refs map[string][]string, previous *state, current, updated *api.Rule) {
+	if current == nil {
+		current = &api.Rule{}
+	}
+	for _, i := range updated.EgressDeny {
+		for _, j := range i.ToCIDR {
+			entry := cidrRefPrefix + string(j)
+			_, tracked := refs[entry]
+			added := !slices.ContainsFunc(current.EgressDeny, func(r api.EgressDenyRule) bool { return slices.Contains(r.ToCIDR, j) })
+			if (tracked || added) && !slices.Contains(previous.Denied, string(j)) {
+				references.Add(refs, entry, previous.TraceID)
+				previous.Denied = append(previous.Denied, string(j))
+			}
+		}
+	}
+	for i := range updated.Egress {
+		entry := ruleRef(&updated.Egress[i])
+		_, tracked := refs[entry]
+		added := !slices.ContainsFunc(current.Egress, func(r api.EgressRule) bool { return egressRuleExists(&r, &updated.Egress[i]) })
+		if tracked || added {
+			references.Add(refs, entry, previous.TraceID)
+			previous.Allowed = append(previous.Allowed, updated.Egress[i])
+		}
+	}
+}
+
+// ruleRef returns the entry of the references for an allow rule
+func ruleRef(rule *api.EgressRule) string {
+	b, _ := json.Marshal(rule)
+	return fmt.Sprintf("%v%x", ruleRefPrefix, sha256.Sum256(b))[:len(ruleRefPrefix)+16]
+}
+
+// Reconcile deletes the ciliumnetworkpolicies with an expired ttl
+func (a Actionner) Reconcile(ctx context.Context) ([]utils.LogLine, error) {
+	ciliumClient := cilium.GetClient()
+	netpols, err := ciliumClient.CiliumV2().CiliumNetworkPolicies("").List(ctx, metav1.ListOptions{LabelSelector: ttl.Selector()})
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]utils.LogLine, 0)
+	for _, i := range netpols.Items {
+		if !ttl.IsExpired(i.ObjectMeta.Annotations[ttl.ExpiresAtAnnotation]) {
+			continue
+		}
+		log := utils.LogLine{
+			Objects: map[string]string{
+				"ciliumnetworkpolicy": i.ObjectMeta.Name,
+				"namespace":           i.ObjectMeta.Namespace,
+			},
+		}
+		err := ciliumClient.CiliumV2().CiliumNetworkPolicies(i.ObjectMeta.Namespace).Delete(ctx, i.ObjectMeta.Name, metav1.DeleteOptions{})
+		if err != nil && !errorsv1.IsNotFound(err) {
+			log.Status = utils.FailureStr
+			log.Error = err.Error()
+		} else {
+			log.Status = utils.SuccessStr
+			log.Output = fmt.Sprintf("the ciliumnetworkpolicy '%v' in the namespace '%v' has expired and has been deleted", i.ObjectMeta.Name, i.ObjectMeta.Namespace)
+		}
+		results = append(results, log)
+	}
+	return results, nil
+}
+
+// createAllowEgressRules returns the egress rules for the allowed CIDRs, namespaces, FQDNs and HTTP requests,
+// all the egress traffic is allowed if none is set
+func createAllowEgressRules(parameters Parameters) []api.EgressRule {
+	rules := make([]api.EgressRule, 0)
+	if parameters.AllowCIDR == nil && parameters.AllowNamespaces == nil && parameters.AllowFQDNs == nil && parameters.AllowHTTP == nil {
+		return append(rules, api.EgressRule{
+			EgressCommonRule: api.EgressCommonRule{
+				ToCIDR: api.CIDRSlice{api.CIDR(anyIPv4)},
+			},
+		})
+	}
+
+	if r := createAllowCIDREgressRule(parameters); r != nil {
+		rules = append(rules, *r)
+	}
+	if r := createAllowNamespaceEgressRule(parameters); r != nil {
+		rules = append(rules, *r)
+	}
+	if len(parameters.AllowFQDNs) != 0 {
+		// the FQDNs are learnt by the DNS proxy of Cilium, the DNS requests must go through it
+		rules = append(rules, createDNSEgressRule())
+	}
+	if r := createAllowFQDNEgressRule(parameters); r != nil {
+		rules = append(rules, *r)
+	}
+	return rules
+}
+
 func createAllowNamespaceEgressRule(parameters Parameters) *api.EgressRule {
-	if len(parameters.AllowCIDR) == 0 {
+	if len(parameters.AllowNamespaces) == 0 {
 		return nil
 	}
 
@@ -418,10 +711,74 @@ func createAllowCIDREgressRule(parameters Parameters) *api.EgressRule {
 	return &rule
 }
 
+// createAllowFQDNEgressRule returns the rule for the allowed FQDNs, restricted to the allowed HTTP requests if set,
+// the HTTP requests to all the destinations are restricted if there's no FQDN
+func createAllowFQDNEgressRule(parameters Parameters) *api.EgressRule {
+	if len(parameters.AllowFQDNs) == 0 && len(parameters.AllowHTTP) == 0 {
+		return nil
+	}
+
+	var rule api.EgressRule
+	for _, i := range parameters.AllowFQDNs {
+		if strings.Contains(i, "*") {
+			rule.ToFQDNs = append(rule.ToFQDNs, api.FQDNSelector{MatchPattern: i})
+		} else {
+			rule.ToFQDNs = append(rule.ToFQDNs, api.FQDNSelector{MatchName: i})
+		}
+	}
+	if len(rule.ToFQDNs) == 0 {
+		rule.ToCIDR = api.CIDRSlice{api.CIDR(anyIPv4)}
+	}
+
+	if len(parameters.AllowHTTP) != 0 {
+		httpPorts := parameters.HTTPPorts
+		if len(httpPorts) == 0 {
+			httpPorts = []int{defaultHTTPPort}
+		}
+		portRule := api.PortRule{Rules: &api.L7Rules{}}
+		for _, i := range httpPorts {
+			portRule.Ports = append(portRule.Ports, api.PortProtocol{Port: strconv.Itoa(i), Protocol: api.ProtoTCP})
+		}
+		for _, i := range parameters.AllowHTTP {
+			portRule.Rules.HTTP = append(portRule.Rules.HTTP, api.PortRuleHTTP{Method: strings.ToUpper(i.Method), Path: i.Path})
+		}
+		rule.ToPorts = api.PortRules{portRule}
+	}
+
+	return &rule
+}
+
+// createDNSEgressRule returns the rule to send the DNS requests to kube-dns through the DNS proxy of Cilium
+func createDNSEgressRule() api.EgressRule {
+	selector := api.EndpointSelector{
+		LabelSelector: &v1.LabelSelector{
+			MatchLabels: map[string]string{
+				podNamespaceKey: "kube-system",
+				"k8s:k8s-app":   "kube-dns",
+			},
+		},
+	}
+	return api.EgressRule{
+		EgressCommonRule: api.EgressCommonRule{
+			ToEndpoints: []api.EndpointSelector{selector},
+		},
+		ToPorts: api.PortRules{
+			{
+				Ports: []api.PortProtocol{{Port: dnsPort, Protocol: api.ProtoAny}},
+				Rules: &api.L7Rules{DNS: []api.PortRuleDNS{{MatchPattern: "*"}}},
+			},
+		},
+	}
+}
+
 func createDenyEgressRule(ips []string) *api.EgressDenyRule {
 	var cidrSlice api.CIDRSlice
 	for _, ip := range ips {
-		cidrSlice = append(cidrSlice, api.CIDR(ip))
+		if net.ParseIP(ip).To4() != nil {
+			cidrSlice = append(cidrSlice, api.CIDR(ip+mask32))
+		} else {
+			cidrSlice = append(cidrSlice, api.CIDR(ip+mask128))
+		}
 	}
 	r := api.EgressDenyRule{
 		EgressCommonRule: api.EgressCommonRule{
@@ -432,12 +789,30 @@ func createDenyEgressRule(ips []string) *api.EgressDenyRule {
 	return &r
 }
 
+// mergeDenyEgressRule adds the denied CIDRs to the first CIDR deny rule, the CIDRs already denied are ignored
+func mergeDenyEgressRule(rules []api.EgressDenyRule, newRule *api.EgressDenyRule) []api.EgressDenyRule {
+	for i := range rules {
+		if len(rules[i].ToCIDR) == 0 || len(rules[i].ToPorts) != 0 {
+			continue
+		}
+		for _, j := range newRule.ToCIDR {
+			if !slices.Contains(rules[i].ToCIDR, j) {
+				rules[i].ToCIDR = append(rules[i].ToCIDR, j)
+			}
+		}
+		return rules
+	}
+	return append(rules, *newRule)
+}
+
 func egressRuleExists(rule *api.EgressRule, newRule *api.EgressRule) bool {
 	return rule.DeepEqual(newRule)
 }
 
-func denyEgressRuleExists(denyEgressRule *api.EgressDenyRule, newDenyEgressRule *api.EgressDenyRule) bool {
-	return denyEgressRule.DeepEqual(newDenyEgressRule)
+// sameEgressRules returns true if the specs have the same egress and egress deny rules
+func sameEgressRules(spec, newSpec *api.Rule) bool {
+	return slices.EqualFunc(spec.Egress, newSpec.Egress, func(i, j api.EgressRule) bool { return i.DeepEqual(&j) }) &&
+		slices.EqualFunc(spec.EgressDeny, newSpec.EgressDeny, func(i, j api.EgressDenyRule) bool { return i.DeepEqual(&j) })
 }
 
 func (a Actionner) CheckParameters(action *rules.Action) error {
@@ -454,6 +829,27 @@ func (a Actionner) CheckParameters(action *rules.Action) error {
 		}
 	}
 
+	for _, i := range parameters.AllowFQDNs {
+		if i == "" || strings.ContainsAny(i, " /:") {
+			return fmt.Errorf("wrong FQDN '%v'", i)
+		}
+	}
+
+	for _, i := range parameters.AllowHTTP {
+		if i.Method == "" && i.Path == "" {
+			return errors.New("the HTTP rules need a method and/or a path")
+		}
+		for _, j := range []string{i.Method, i.Path} {
+			if _, err2 := regexp.Compile(j); err2 != nil {
+				return fmt.Errorf("wrong regular expression '%v' in the HTTP rules", j)
+			}
+		}
+	}
+
+	if err := ttl.Check(parameters.TTL); err != nil {
+		return err
+	}
+
 	err = utils.ValidateStruct(parameters)
 	if err != nil {
 		return err
@@ -0,0 +1,97 @@
+package actionners
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/falcosecurity/falco-talon/configuration"
+	"github.com/falcosecurity/falco-talon/internal/deadletters"
+	"github.com/falcosecurity/falco-talon/internal/events"
+	"github.com/falcosecurity/falco-talon/internal/rules"
+	"github.com/falcosecurity/falco-talon/utils"
+)
+
+type redrivesKey struct{}
+
+// withRedrives returns a context for the redrive of a dead letter, with the number of redrives of the action
+func withRedrives(ctx context.Context, redrives int) context.Context {
+	return context.WithValue(ctx, redrivesKey{}, redrives)
+}
+
+// recordFailure records the failed action as a dead letter. With the acknowledgement after processing, the
+// action and its dependents are redriven after ack_wait_seconds, until max_deliver runs, rather than the
+// delivery of the whole event again
+func recordFailure(mctx context.Context, rule *rules.Rule, action *rules.Action, event *events.Event, log utils.LogLine, attempts int) {
+	redrives, _ := mctx.Value(redrivesKey{}).(int)
+	e := &deadletters.Entry{
+		Time:      time.Now().UTC(),
+		Event:     event,
+		ID:        uuid.NewString(),
+		Rule:      rule.GetName(),
+		Action:    action.GetName(),
+		Actionner: action.GetActionner(),
+		Error:     log.Error,
+		Status:    log.Status,
+		Attempts:  attempts,
+		Redrives:  redrives,
+		Approved:  isApproved(mctx, action),
+	}
+	err := deadletters.Add(e)
+	if err != nil {
+		utils.PrintLog("error", utils.LogLine{
+			Message:   "deadletter",
+			Rule:      rule.GetName(),
+			Action:    action.GetName(),
+			Actionner: action.GetActionner(),
+			TraceID:   event.TraceID,
+			Error:     fmt.Sprintf("can't record the failed action: %v", err.Error()),
+		})
+		return
+	}
+
+	config := configuration.GetConfiguration().NATS
+	if config.AckAfterProcessing && redrives+1 < config.MaxDeliver {
+		// the dead letter may have been redriven by hand meanwhile, it's then not found
+		time.AfterFunc(time.Duration(config.AckWaitSeconds)*time.Second, func() { _ = Redrive(e.ID) })
+	}
+}
+
+// Redrive runs again a failed action and then the actions depending on it, if it fails again, a new dead letter is recorded
+func Redrive(id string) error {
+	e, err := deadletters.Take(id)
+	if err != nil {
+		return err
+	}
+
+	log := utils.LogLine{
+		Message:   "deadletter",
+		Rule:      e.Rule,
+		Action:    e.Action,
+		Actionner: e.Actionner,
+		TraceID:   e.Event.TraceID,
+		Result:    id,
+	}
+	rule, action := findAction(e.Rule, e.Action)
+	if action == nil {
+		log.Status = utils.FailureStr
+		log.Error = "the rule or the action doesn't exist anymore"
+		utils.PrintLog("error", log)
+		// the dead letter is kept for a next attempt
+		_ = deadletters.Add(e)
+		return fmt.Errorf("the action '%v' of the rule '%v' doesn't exist anymore", e.Action, e.Rule)
+	}
+	log.Output = "the action is redriven"
+	utils.PrintLog("info", log)
+
+	// the approval is granted again only if it was granted before the failure, a new approval is
+	// required otherwise, if the action requires one
+	ctx := withRedrives(context.Background(), e.Redrives+1)
+	if e.Approved {
+		ctx = withApproval(ctx, action)
+	}
+	go resumeActions(ctx, rule, action, e.Event)
+	return nil
+}
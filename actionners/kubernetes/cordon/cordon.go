@@ -2,6 +2,7 @@ package cordon
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -12,6 +13,7 @@ import (
 	k8s "github.com/falcosecurity/falco-talon/internal/kubernetes/client"
 	"github.com/falcosecurity/falco-talon/internal/models"
 	"github.com/falcosecurity/falco-talon/internal/rules"
+	"github.com/falcosecurity/falco-talon/internal/ttl"
 	"github.com/falcosecurity/falco-talon/utils"
 )
 
@@ -44,12 +46,12 @@ rules:
   - get
   - update
   - patch
+  - list
 `
-	Example string = `- action: Exec a command into the pod
-  actionner: kubernetes:exec
+	Example string = `- action: Cordon the node
+  actionner: kubernetes:cordon
   parameters:
-    shell: /bin/bash
-    command: "cat ${FD_NAME}"
+    ttl: 1h
 `
 )
 
@@ -57,9 +59,15 @@ var (
 	RequiredOutputFields = []string{"k8s.ns.name", "k8s.pod.name"}
 )
 
-const (
-	jsonPatch = `[{"op": "replace", "path": "/spec/unschedulable", "value": true}]`
-)
+type Parameters struct {
+	TTL string `mapstructure:"ttl" validate:"omitempty"`
+}
+
+// state is saved to revert the cordon
+type state struct {
+	Node          string `json:"node"`
+	Unschedulable bool   `json:"unschedulable"`
+}
 
 type Actionner struct{}
 
@@ -87,22 +95,34 @@ func (a Actionner) Information() models.Information {
 	}
 }
 func (a Actionner) Parameters() models.Parameters {
-	return nil
+	return Parameters{
+		TTL: "",
+	}
 }
 
 func (a Actionner) Checks(event *events.Event, _ *rules.Action) error {
 	return k8sChecks.CheckPodExist(event)
 }
 
-func (a Actionner) Run(event *events.Event, _ *rules.Action) (utils.LogLine, *models.Data, error) {
+func (a Actionner) Run(ctx context.Context, event *events.Event, action *rules.Action) (utils.LogLine, *models.Data, error) {
 	podName := event.GetPodName()
 	namespace := event.GetNamespaceName()
 
 	objects := map[string]string{}
 
+	var parameters Parameters
+	err := utils.DecodeParams(action.GetParameters(), &parameters)
+	if err != nil {
+		return utils.LogLine{
+			Objects: nil,
+			Error:   err.Error(),
+			Status:  utils.FailureStr,
+		}, nil, err
+	}
+
 	client := k8s.GetClient()
 
-	pod, err := client.GetPod(podName, namespace)
+	pod, err := client.GetPod(ctx, podName, namespace)
 	if err != nil {
 		objects["pod"] = podName
 		objects["namespace"] = namespace
@@ -113,7 +133,7 @@ func (a Actionner) Run(event *events.Event, _ *rules.Action) (utils.LogLine, *mo
 		}, nil, err
 	}
 
-	node, err := client.GetNodeFromPod(pod)
+	node, err := client.GetNodeFromPod(ctx, pod)
 	if err != nil {
 		return utils.LogLine{
 			Objects: objects,
@@ -124,7 +144,27 @@ func (a Actionner) Run(event *events.Event, _ *rules.Action) (utils.LogLine, *mo
 
 	objects["node"] = node.Name
 
-	_, err = client.Clientset.CoreV1().Nodes().Patch(context.Background(), node.Name, types.JSONPatchType, []byte(jsonPatch), metav1.PatchOptions{})
+	rollback, _ := json.Marshal(state{Node: node.Name, Unschedulable: node.Spec.Unschedulable})
+
+	// a node already cordoned without ttl stays cordoned
+	var expiresAt *string
+	if !node.Spec.Unschedulable {
+		if parameters.TTL != "" {
+			v := ttl.ExpiresAt(parameters.TTL)
+			expiresAt = &v
+		}
+	} else {
+		v, ok := node.ObjectMeta.Annotations[ttl.CordonAnnotation]
+		if v, keep := ttl.Update(v, ok, parameters.TTL); keep {
+			expiresAt = &v
+		}
+	}
+
+	payload, _ := json.Marshal(map[string]any{
+		"metadata": ttl.MetadataPatch(node.ObjectMeta.Annotations, ttl.CordonAnnotation, expiresAt),
+		"spec":     map[string]any{"unschedulable": true},
+	})
+	_, err = client.Clientset.CoreV1().Nodes().Patch(ctx, node.Name, types.MergePatchType, payload, metav1.PatchOptions{})
 	if err != nil {
 		return utils.LogLine{
 			Objects: objects,
@@ -137,7 +177,91 @@ func (a Actionner) Run(event *events.Event, _ *rules.Action) (utils.LogLine, *mo
 		Objects: objects,
 		Output:  fmt.Sprintf("the node '%v' has been cordoned", node.Name),
 		Status:  utils.SuccessStr,
-	}, nil, nil
+	}, &models.Data{Rollback: rollback}, nil
 }
 
-func (a Actionner) CheckParameters(_ *rules.Action) error { return nil }
+func (a Actionner) Rollback(ctx context.Context, b []byte) (utils.LogLine, error) {
+	var previous state
+	if err := json.Unmarshal(b, &previous); err != nil {
+		return utils.LogLine{Status: utils.FailureStr, Error: err.Error()}, err
+	}
+
+	objects := map[string]string{"node": previous.Node}
+
+	client := k8s.GetClient()
+	node, err := client.GetNode(ctx, previous.Node)
+	if err != nil {
+		return utils.LogLine{
+			Objects: objects,
+			Error:   err.Error(),
+			Status:  utils.FailureStr,
+		}, err
+	}
+
+	payload, _ := json.Marshal(map[string]any{
+		"metadata": ttl.MetadataPatch(node.ObjectMeta.Annotations, ttl.CordonAnnotation, nil),
+		"spec":     map[string]any{"unschedulable": previous.Unschedulable},
+	})
+	_, err = client.Clientset.CoreV1().Nodes().Patch(ctx, previous.Node, types.MergePatchType, payload, metav1.PatchOptions{})
+	if err != nil {
+		return utils.LogLine{
+			Objects: objects,
+			Error:   err.Error(),
+			Status:  utils.FailureStr,
+		}, err
+	}
+
+	return utils.LogLine{
+		Objects: objects,
+		Output:  fmt.Sprintf("the node '%v' has been restored", previous.Node),
+		Status:  utils.SuccessStr,
+	}, nil
+}
+
+// Reconcile uncordons the nodes with an expired ttl
+func (a Actionner) Reconcile(ctx context.Context) ([]utils.LogLine, error) {
+	client := k8s.GetClient()
+	nodes, err := client.Clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{LabelSelector: ttl.Selector()})
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]utils.LogLine, 0)
+	for _, i := range nodes.Items {
+		v, ok := i.ObjectMeta.Annotations[ttl.CordonAnnotation]
+		if !ok || !ttl.IsExpired(v) {
+			continue
+		}
+		log := utils.LogLine{
+			Objects: map[string]string{"node": i.ObjectMeta.Name},
+		}
+		payload, _ := json.Marshal(map[string]any{
+			"metadata": ttl.MetadataPatch(i.ObjectMeta.Annotations, ttl.CordonAnnotation, nil),
+			"spec":     map[string]any{"unschedulable": false},
+		})
+		_, err := client.Clientset.CoreV1().Nodes().Patch(ctx, i.ObjectMeta.Name, types.MergePatchType, payload, metav1.PatchOptions{})
+		if err != nil {
+			log.Status = utils.FailureStr
+			log.Error = err.Error()
+		} else {
+			log.Status = utils.SuccessStr
+			log.Output = fmt.Sprintf("the cordon of the node '%v' has expired, the node has been uncordoned", i.ObjectMeta.Name)
+		}
+		results = append(results, log)
+	}
+	return results, nil
+}
+
+func (a Actionner) CheckParameters(action *rules.Action) error {
+	var parameters Parameters
+	err := utils.DecodeParams(action.GetParameters(), &parameters)
+	if err != nil {
+		return err
+	}
+
+	if err := ttl.Check(parameters.TTL); err != nil {
+		return err
+	}
+
+	return utils.ValidateStruct(parameters)
+}
@@ -145,7 +145,7 @@ func (a Actionner) Checks(event *events.Event, _ *rules.Action) error {
 	return k8sChecks.CheckTargetExist(event)
 }
 
-func (a Actionner) Run(event *events.Event, _ *rules.Action) (utils.LogLine, *models.Data, error) {
+func (a Actionner) Run(ctx context.Context, event *events.Event, _ *rules.Action) (utils.LogLine, *models.Data, error) {
 	name := event.GetTargetName()
 	resource := event.GetTargetResource()
 	namespace := event.GetTargetNamespace()
@@ -162,27 +162,27 @@ func (a Actionner) Run(event *events.Event, _ *rules.Action) (utils.LogLine, *mo
 
 	switch resource {
 	case namespaces:
-		err = client.Clientset.CoreV1().Namespaces().Delete(context.Background(), name, metav1.DeleteOptions{})
+		err = client.Clientset.CoreV1().Namespaces().Delete(ctx, name, metav1.DeleteOptions{})
 	case "configmaps":
-		err = client.Clientset.CoreV1().ConfigMaps(namespace).Delete(context.Background(), name, metav1.DeleteOptions{})
+		err = client.Clientset.CoreV1().ConfigMaps(namespace).Delete(ctx, name, metav1.DeleteOptions{})
 	case "secrets":
-		err = client.Clientset.CoreV1().Secrets(namespace).Delete(context.Background(), name, metav1.DeleteOptions{})
+		err = client.Clientset.CoreV1().Secrets(namespace).Delete(ctx, name, metav1.DeleteOptions{})
 	case "deployments":
-		err = client.Clientset.AppsV1().Deployments(namespace).Delete(context.Background(), name, metav1.DeleteOptions{})
+		err = client.Clientset.AppsV1().Deployments(namespace).Delete(ctx, name, metav1.DeleteOptions{})
 	case "daemonsets":
-		err = client.Clientset.AppsV1().DaemonSets(namespace).Delete(context.Background(), name, metav1.DeleteOptions{})
+		err = client.Clientset.AppsV1().DaemonSets(namespace).Delete(ctx, name, metav1.DeleteOptions{})
 	case "statefulsets":
-		err = client.Clientset.AppsV1().StatefulSets(namespace).Delete(context.Background(), name, metav1.DeleteOptions{})
+		err = client.Clientset.AppsV1().StatefulSets(namespace).Delete(ctx, name, metav1.DeleteOptions{})
 	case "replicasets":
-		err = client.Clientset.AppsV1().ReplicaSets(namespace).Delete(context.Background(), name, metav1.DeleteOptions{})
+		err = client.Clientset.AppsV1().ReplicaSets(namespace).Delete(ctx, name, metav1.DeleteOptions{})
 	case "services":
-		err = client.Clientset.CoreV1().Services(namespace).Delete(context.Background(), name, metav1.DeleteOptions{})
+		err = client.Clientset.CoreV1().Services(namespace).Delete(ctx, name, metav1.DeleteOptions{})
 	case "serviceaccounts":
-		err = client.Clientset.CoreV1().ServiceAccounts(namespace).Delete(context.Background(), name, metav1.DeleteOptions{})
+		err = client.Clientset.CoreV1().ServiceAccounts(namespace).Delete(ctx, name, metav1.DeleteOptions{})
 	case "roles":
-		err = client.Clientset.RbacV1().Roles(namespace).Delete(context.Background(), name, metav1.DeleteOptions{})
+		err = client.Clientset.RbacV1().Roles(namespace).Delete(ctx, name, metav1.DeleteOptions{})
 	case "clusterroles":
-		err = client.Clientset.RbacV1().ClusterRoles().Delete(context.Background(), name, metav1.DeleteOptions{})
+		err = client.Clientset.RbacV1().ClusterRoles().Delete(ctx, name, metav1.DeleteOptions{})
 	}
 
 	if err != nil {
@@ -114,12 +114,12 @@ func (a Actionner) Checks(event *events.Event, _ *rules.Action) error {
 	return k8sChecks.CheckPodExist(event)
 }
 
-func (a Actionner) Run(event *events.Event, action *rules.Action) (utils.LogLine, *models.Data, error) {
+func (a Actionner) Run(ctx context.Context, event *events.Event, action *rules.Action) (utils.LogLine, *models.Data, error) {
 	client := k8s.GetClient()
-	return a.RunWithClient(*client, event, action)
+	return a.RunWithClient(ctx, *client, event, action)
 }
 
-func (a Actionner) RunWithClient(client k8s.DrainClient, event *events.Event, action *rules.Action) (utils.LogLine, *models.Data, error) {
+func (a Actionner) RunWithClient(ctx context.Context, client k8s.DrainClient, event *events.Event, action *rules.Action) (utils.LogLine, *models.Data, error) {
 	podName := event.GetPodName()
 	namespace := event.GetNamespaceName()
 	objects := map[string]string{}
@@ -134,7 +134,7 @@ func (a Actionner) RunWithClient(client k8s.DrainClient, event *events.Event, ac
 		}, nil, err
 	}
 
-	pod, err := client.GetPod(podName, namespace)
+	pod, err := client.GetPod(ctx, podName, namespace)
 	if err != nil {
 		objects["pod"] = podName
 		objects["namespace"] = namespace
@@ -145,7 +145,7 @@ func (a Actionner) RunWithClient(client k8s.DrainClient, event *events.Event, ac
 		}, nil, err
 	}
 
-	node, err := client.GetNodeFromPod(pod)
+	node, err := client.GetNodeFromPod(ctx, pod)
 	if err != nil {
 		objects["pod"] = podName
 		objects["namespace"] = namespace
@@ -158,7 +158,7 @@ func (a Actionner) RunWithClient(client k8s.DrainClient, event *events.Event, ac
 	nodeName := node.GetName()
 	objects["node"] = nodeName
 
-	pods, err := client.ListPods(context.Background(), metav1.ListOptions{
+	pods, err := client.ListPods(ctx, metav1.ListOptions{
 		FieldSelector: fmt.Sprintf("spec.nodeName=%s", nodeName),
 	})
 	if err != nil {
@@ -187,8 +187,10 @@ func (a Actionner) RunWithClient(client k8s.DrainClient, event *events.Event, ac
 			select {
 			case <-stopListingDone:
 				return
+			case <-ctx.Done():
+				return
 			case <-ticker.C:
-				pods2, err2 := client.ListPods(context.Background(), metav1.ListOptions{
+				pods2, err2 := client.ListPods(ctx, metav1.ListOptions{
 					FieldSelector: fmt.Sprintf("spec.nodeName=%s", nodeName),
 				})
 				if err2 != nil {
@@ -246,7 +248,7 @@ func (a Actionner) RunWithClient(client k8s.DrainClient, event *events.Event, ac
 					return
 				}
 				if parameters.MinHealthyReplicas != "" {
-					replicaSet, err := client.GetReplicaSet(replicaSetName, p.Namespace)
+					replicaSet, err := client.GetReplicaSet(ctx, replicaSetName, p.Namespace)
 					if err != nil {
 						utils.PrintLog("warning", utils.LogLine{Message: fmt.Sprintf("error getting replica set for pod '%v': %v", p.Name, err)})
 						atomic.AddInt32(&otherErrorsCount, 1)
@@ -286,7 +288,7 @@ func (a Actionner) RunWithClient(client k8s.DrainClient, event *events.Event, ac
 				}
 			}
 
-			if err := client.EvictPod(p); err != nil {
+			if err := client.EvictPod(ctx, p); err != nil {
 				utils.PrintLog("warning", utils.LogLine{Message: fmt.Sprintf("error evicting pod '%v': %v", p.Name, err)})
 				atomic.AddInt32(&evictionErrorsCount, 1)
 				return
@@ -299,6 +301,9 @@ func (a Actionner) RunWithClient(client k8s.DrainClient, event *events.Event, ac
 
 				for {
 					select {
+					case <-ctx.Done():
+						atomic.AddInt32(&evictionWaitPeriodErrorsCount, 1)
+						return
 					case <-timeout:
 						utils.PrintLog("error", utils.LogLine{Message: fmt.Sprintf("pod '%v' did not terminate within the max_wait_period", pod.Name)})
 						atomic.AddInt32(&evictionWaitPeriodErrorsCount, 1)
@@ -2,6 +2,7 @@ package exec
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"os"
 
@@ -95,7 +96,7 @@ func (a Actionner) Checks(event *events.Event, _ *rules.Action) error {
 	return k8sChecks.CheckPodExist(event)
 }
 
-func (a Actionner) Run(event *events.Event, action *rules.Action) (utils.LogLine, *models.Data, error) {
+func (a Actionner) Run(ctx context.Context, event *events.Event, action *rules.Action) (utils.LogLine, *models.Data, error) {
 	pod := event.GetPodName()
 	namespace := event.GetNamespaceName()
 
@@ -129,7 +130,7 @@ func (a Actionner) Run(event *events.Event, action *rules.Action) (utils.LogLine
 
 	client := k8s.GetClient()
 
-	p, _ := client.GetPod(pod, namespace)
+	p, _ := client.GetPod(ctx, pod, namespace)
 	containers := k8s.GetContainers(p)
 	if len(containers) == 0 {
 		err = fmt.Errorf("no container found")
@@ -143,7 +144,7 @@ func (a Actionner) Run(event *events.Event, action *rules.Action) (utils.LogLine
 	output := new(bytes.Buffer)
 	for i, container := range containers {
 		command := []string{*shell, "-c", *command}
-		output, err = client.Exec(namespace, pod, container, command, "")
+		output, err = client.Exec(ctx, namespace, pod, container, command, "")
 		if err != nil {
 			if i == len(containers)-1 {
 				return utils.LogLine{
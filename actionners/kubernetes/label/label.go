@@ -16,6 +16,7 @@ import (
 	k8s "github.com/falcosecurity/falco-talon/internal/kubernetes/client"
 	"github.com/falcosecurity/falco-talon/internal/models"
 	"github.com/falcosecurity/falco-talon/internal/rules"
+	"github.com/falcosecurity/falco-talon/internal/ttl"
 	"github.com/falcosecurity/falco-talon/utils"
 )
 
@@ -42,6 +43,15 @@ rules:
   - update
   - patch
   - list
+- apiGroups:
+  - ""
+  resources:
+  - nodes
+  verbs:
+  - get
+  - update
+  - patch
+  - list
 `
 	Example string = `- action: Label the pod
   actionner: kubernetes:label
@@ -49,6 +59,7 @@ rules:
     level: pod
     labels:
       suspicious: true
+    ttl: 24h
 `
 )
 
@@ -62,9 +73,24 @@ type patch struct {
 	Value string `json:"value,omitempty"`
 }
 
+// state is saved to revert the labels
+type state struct {
+	Labels    map[string]*string `json:"labels"`
+	Kind      string             `json:"kind"`
+	Name      string             `json:"name"`
+	Namespace string             `json:"namespace,omitempty"`
+}
+
 type Parameters struct {
 	Labels map[string]string `mapstructure:"labels" validate:"required"`
 	Level  string            `mapstructure:"level" validate:"omitempty"`
+	TTL    string            `mapstructure:"ttl" validate:"omitempty"`
+}
+
+// expiration is the date of expiration of a label and its value before Falco Talon
+type expiration struct {
+	Previous  *string `json:"previous"`
+	ExpiresAt string  `json:"expires_at"`
 }
 
 const (
@@ -102,6 +128,7 @@ func (a Actionner) Parameters() models.Parameters {
 	return Parameters{
 		Labels: map[string]string{},
 		Level:  "pod",
+		TTL:    "",
 	}
 }
 
@@ -109,7 +136,7 @@ func (a Actionner) Checks(event *events.Event, _ *rules.Action) error {
 	return k8sChecks.CheckPodExist(event)
 }
 
-func (a Actionner) Run(event *events.Event, action *rules.Action) (utils.LogLine, *models.Data, error) {
+func (a Actionner) Run(ctx context.Context, event *events.Event, action *rules.Action) (utils.LogLine, *models.Data, error) {
 	podName := event.GetPodName()
 	namespace := event.GetNamespaceName()
 
@@ -131,10 +158,11 @@ func (a Actionner) Run(event *events.Event, action *rules.Action) (utils.LogLine
 
 	var kind string
 	var node *corev1.Node
+	var current, annotations map[string]string
 
 	if parameters.Level == nodeStr {
 		kind = nodeStr
-		pod, err2 := client.GetPod(podName, namespace)
+		pod, err2 := client.GetPod(ctx, podName, namespace)
 		if err2 != nil {
 			return utils.LogLine{
 				Objects: objects,
@@ -142,7 +170,7 @@ func (a Actionner) Run(event *events.Event, action *rules.Action) (utils.LogLine
 				Status:  utils.FailureStr,
 			}, nil, err2
 		}
-		node, err = client.GetNodeFromPod(pod)
+		node, err = client.GetNodeFromPod(ctx, pod)
 		if err != nil {
 			return utils.LogLine{
 				Objects: objects,
@@ -151,12 +179,43 @@ func (a Actionner) Run(event *events.Event, action *rules.Action) (utils.LogLine
 			}, nil, err
 		}
 		objects[nodeStr] = node.Name
+		current = node.Labels
+		annotations = node.Annotations
 	} else {
 		kind = podStr
 		objects[podStr] = podName
 		objects["namespace"] = namespace
+		pod, err2 := client.GetPod(ctx, podName, namespace)
+		if err2 != nil {
+			return utils.LogLine{
+				Objects: objects,
+				Error:   err2.Error(),
+				Status:  utils.FailureStr,
+			}, nil, err2
+		}
+		current = pod.Labels
+		annotations = pod.Annotations
 	}
 
+	previous := state{
+		Kind:      kind,
+		Name:      podName,
+		Namespace: namespace,
+		Labels:    make(map[string]*string, len(parameters.Labels)),
+	}
+	if kind == nodeStr {
+		previous.Name = node.Name
+		previous.Namespace = ""
+	}
+	for i := range parameters.Labels {
+		if v, ok := current[i]; ok {
+			previous.Labels[i] = &v
+		} else {
+			previous.Labels[i] = nil
+		}
+	}
+	rollback, _ := json.Marshal(previous)
+
 	for i, j := range parameters.Labels {
 		if fmt.Sprintf("%v", j) == "" {
 			continue
@@ -170,10 +229,10 @@ func (a Actionner) Run(event *events.Event, action *rules.Action) (utils.LogLine
 
 	payloadBytes, _ := json.Marshal(payload)
 	if kind == podStr {
-		_, err = client.Clientset.CoreV1().Pods(namespace).Patch(context.Background(), podName, types.JSONPatchType, payloadBytes, metav1.PatchOptions{})
+		_, err = client.Clientset.CoreV1().Pods(namespace).Patch(ctx, podName, types.JSONPatchType, payloadBytes, metav1.PatchOptions{})
 	}
 	if kind == nodeStr {
-		_, err = client.Clientset.CoreV1().Nodes().Patch(context.Background(), node.Name, types.JSONPatchType, payloadBytes, metav1.PatchOptions{})
+		_, err = client.Clientset.CoreV1().Nodes().Patch(ctx, node.Name, types.JSONPatchType, payloadBytes, metav1.PatchOptions{})
 	}
 	if err != nil {
 		return utils.LogLine{
@@ -197,9 +256,9 @@ func (a Actionner) Run(event *events.Event, action *rules.Action) (utils.LogLine
 
 	payloadBytes, _ = json.Marshal(payload)
 	if kind == nodeStr {
-		_, err = client.Clientset.CoreV1().Nodes().Patch(context.Background(), node.Name, types.JSONPatchType, payloadBytes, metav1.PatchOptions{})
+		_, err = client.Clientset.CoreV1().Nodes().Patch(ctx, node.Name, types.JSONPatchType, payloadBytes, metav1.PatchOptions{})
 	} else {
-		_, err = client.Clientset.CoreV1().Pods(namespace).Patch(context.Background(), podName, types.JSONPatchType, payloadBytes, metav1.PatchOptions{})
+		_, err = client.Clientset.CoreV1().Pods(namespace).Patch(ctx, podName, types.JSONPatchType, payloadBytes, metav1.PatchOptions{})
 	}
 	if err != nil {
 		if err.Error() != "the server rejected our request due to an error in our request" {
@@ -210,6 +269,22 @@ func (a Actionner) Run(event *events.Event, action *rules.Action) (utils.LogLine
 			}, nil, err
 		}
 	}
+	if metadata := expirationPatch(current, annotations, &parameters); metadata != nil {
+		payloadBytes, _ = json.Marshal(map[string]any{"metadata": metadata})
+		if kind == nodeStr {
+			_, err = client.Clientset.CoreV1().Nodes().Patch(ctx, node.Name, types.MergePatchType, payloadBytes, metav1.PatchOptions{})
+		} else {
+			_, err = client.Clientset.CoreV1().Pods(namespace).Patch(ctx, podName, types.MergePatchType, payloadBytes, metav1.PatchOptions{})
+		}
+		if err != nil {
+			return utils.LogLine{
+				Objects: objects,
+				Error:   err.Error(),
+				Status:  utils.FailureStr,
+			}, nil, err
+		}
+	}
+
 	var output string
 	if kind == nodeStr {
 		output = fmt.Sprintf("the node '%v' has been labeled", node.Name)
@@ -220,7 +295,177 @@ func (a Actionner) Run(event *events.Event, action *rules.Action) (utils.LogLine
 		Objects: objects,
 		Output:  output,
 		Status:  utils.SuccessStr,
-	}, nil, nil
+	}, &models.Data{Rollback: rollback}, nil
+}
+
+func (a Actionner) Rollback(ctx context.Context, b []byte) (utils.LogLine, error) {
+	var previous state
+	if err := json.Unmarshal(b, &previous); err != nil {
+		return utils.LogLine{Status: utils.FailureStr, Error: err.Error()}, err
+	}
+
+	objects := map[string]string{previous.Kind: previous.Name}
+	if previous.Namespace != "" {
+		objects["namespace"] = previous.Namespace
+	}
+
+	payload, _ := json.Marshal(map[string]any{"metadata": map[string]any{"labels": previous.Labels}})
+
+	var err error
+	client := k8s.GetClient()
+	if previous.Kind == nodeStr {
+		_, err = client.Clientset.CoreV1().Nodes().Patch(ctx, previous.Name, types.MergePatchType, payload, metav1.PatchOptions{})
+	} else {
+		_, err = client.Clientset.CoreV1().Pods(previous.Namespace).Patch(ctx, previous.Name, types.MergePatchType, payload, metav1.PatchOptions{})
+	}
+	if err != nil {
+		return utils.LogLine{
+			Objects: objects,
+			Error:   err.Error(),
+			Status:  utils.FailureStr,
+		}, err
+	}
+
+	var output string
+	if previous.Kind == nodeStr {
+		output = fmt.Sprintf("the labels of the node '%v' have been restored", previous.Name)
+	} else {
+		output = fmt.Sprintf("the labels of the pod '%v' in the namespace '%v' have been restored", previous.Name, previous.Namespace)
+	}
+	return utils.LogLine{
+		Objects: objects,
+		Output:  output,
+		Status:  utils.SuccessStr,
+	}, nil
+}
+
+// expirationPatch returns the metadata of the patch to record the expirations of the labels,
+// the labels set without ttl become permanent
+func expirationPatch(current, annotations map[string]string, parameters *Parameters) map[string]any {
+	v, ok := annotations[ttl.LabelsAnnotation]
+	if !ok && parameters.TTL == "" {
+		return nil
+	}
+
+	expirations := make(map[string]expiration)
+	if ok {
+		_ = json.Unmarshal([]byte(v), &expirations)
+	}
+	for i := range parameters.Labels {
+		if parameters.TTL == "" {
+			delete(expirations, i)
+			continue
+		}
+		expiresAt := ttl.ExpiresAt(parameters.TTL)
+		if e, ok := expirations[i]; ok {
+			e.ExpiresAt = ttl.Latest(e.ExpiresAt, expiresAt)
+			expirations[i] = e
+			continue
+		}
+		e := expiration{ExpiresAt: expiresAt}
+		if c, ok := current[i]; ok {
+			e.Previous = &c
+		}
+		expirations[i] = e
+	}
+
+	return expirationsMetadata(annotations, expirations, nil)
+}
+
+func expirationsMetadata(annotations map[string]string, expirations map[string]expiration, restored map[string]*string) map[string]any {
+	var value *string
+	if len(expirations) != 0 {
+		b, _ := json.Marshal(expirations)
+		v := string(b)
+		value = &v
+	}
+	metadata := ttl.MetadataPatch(annotations, ttl.LabelsAnnotation, value)
+	labels := metadata["labels"].(map[string]*string)
+	for i, j := range restored {
+		labels[i] = j
+	}
+	return metadata
+}
+
+// Reconcile restores the labels with an expired ttl
+func (a Actionner) Reconcile(ctx context.Context) ([]utils.LogLine, error) {
+	client := k8s.GetClient()
+	opts := metav1.ListOptions{LabelSelector: ttl.Selector()}
+
+	results := make([]utils.LogLine, 0)
+
+	pods, err := client.Clientset.CoreV1().Pods("").List(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	for _, i := range pods.Items {
+		metadata := restoreExpiredLabels(i.ObjectMeta.Annotations)
+		if metadata == nil {
+			continue
+		}
+		log := utils.LogLine{
+			Objects: map[string]string{podStr: i.ObjectMeta.Name, "namespace": i.ObjectMeta.Namespace},
+		}
+		payload, _ := json.Marshal(map[string]any{"metadata": metadata})
+		_, err := client.Clientset.CoreV1().Pods(i.ObjectMeta.Namespace).Patch(ctx, i.ObjectMeta.Name, types.MergePatchType, payload, metav1.PatchOptions{})
+		if err != nil {
+			log.Status = utils.FailureStr
+			log.Error = err.Error()
+		} else {
+			log.Status = utils.SuccessStr
+			log.Output = fmt.Sprintf("the labels of the pod '%v' in the namespace '%v' have expired and have been restored", i.ObjectMeta.Name, i.ObjectMeta.Namespace)
+		}
+		results = append(results, log)
+	}
+
+	nodes, err := client.Clientset.CoreV1().Nodes().List(ctx, opts)
+	if err != nil {
+		return results, err
+	}
+	for _, i := range nodes.Items {
+		metadata := restoreExpiredLabels(i.ObjectMeta.Annotations)
+		if metadata == nil {
+			continue
+		}
+		log := utils.LogLine{
+			Objects: map[string]string{nodeStr: i.ObjectMeta.Name},
+		}
+		payload, _ := json.Marshal(map[string]any{"metadata": metadata})
+		_, err := client.Clientset.CoreV1().Nodes().Patch(ctx, i.ObjectMeta.Name, types.MergePatchType, payload, metav1.PatchOptions{})
+		if err != nil {
+			log.Status = utils.FailureStr
+			log.Error = err.Error()
+		} else {
+			log.Status = utils.SuccessStr
+			log.Output = fmt.Sprintf("the labels of the node '%v' have expired and have been restored", i.ObjectMeta.Name)
+		}
+		results = append(results, log)
+	}
+
+	return results, nil
+}
+
+// restoreExpiredLabels returns the metadata of the patch to restore the expired labels, nil if none has expired
+func restoreExpiredLabels(annotations map[string]string) map[string]any {
+	v, ok := annotations[ttl.LabelsAnnotation]
+	if !ok {
+		return nil
+	}
+	expirations := make(map[string]expiration)
+	if err := json.Unmarshal([]byte(v), &expirations); err != nil {
+		return nil
+	}
+	restored := make(map[string]*string)
+	for i, j := range expirations {
+		if ttl.IsExpired(j.ExpiresAt) {
+			restored[i] = j.Previous
+			delete(expirations, i)
+		}
+	}
+	if len(restored) == 0 {
+		return nil
+	}
+	return expirationsMetadata(annotations, expirations, restored)
 }
 
 func (a Actionner) CheckParameters(action *rules.Action) error {
@@ -238,5 +483,9 @@ func (a Actionner) CheckParameters(action *rules.Action) error {
 	if len(parameters.Labels) == 0 {
 		return errors.New("parameter 'labels' should have at least one label")
 	}
+
+	if err := ttl.Check(parameters.TTL); err != nil {
+		return err
+	}
 	return nil
 }
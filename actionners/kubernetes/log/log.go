@@ -103,7 +103,7 @@ func (a Actionner) Checks(event *events.Event, _ *rules.Action) error {
 	return k8sChecks.CheckPodExist(event)
 }
 
-func (a Actionner) Run(event *events.Event, action *rules.Action) (utils.LogLine, *models.Data, error) {
+func (a Actionner) Run(ctx context.Context, event *events.Event, action *rules.Action) (utils.LogLine, *models.Data, error) {
 	pod := event.GetPodName()
 	namespace := event.GetNamespaceName()
 
@@ -130,7 +130,7 @@ func (a Actionner) Run(event *events.Event, action *rules.Action) (utils.LogLine
 
 	client := k8s.GetClient()
 
-	p, _ := client.GetPod(pod, namespace)
+	p, _ := client.GetPod(ctx, pod, namespace)
 	containers := k8s.GetContainers(p)
 	if len(containers) == 0 {
 		err := fmt.Errorf("no container found")
@@ -141,7 +141,6 @@ func (a Actionner) Run(event *events.Event, action *rules.Action) (utils.LogLine
 		}, nil, err
 	}
 
-	ctx := context.Background()
 	var output []byte
 
 	for i, container := range containers {
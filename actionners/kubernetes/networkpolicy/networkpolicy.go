@@ -2,25 +2,35 @@ package networkpolicy
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"net"
+	"slices"
+	"strings"
 
+	corev1 "k8s.io/api/core/v1"
 	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	errorsv1 "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/util/retry"
 
 	"github.com/falcosecurity/falco-talon/internal/events"
 	k8sChecks "github.com/falcosecurity/falco-talon/internal/kubernetes/checks"
 	k8s "github.com/falcosecurity/falco-talon/internal/kubernetes/client"
 	"github.com/falcosecurity/falco-talon/internal/models"
+	"github.com/falcosecurity/falco-talon/internal/references"
 	"github.com/falcosecurity/falco-talon/internal/rules"
+	"github.com/falcosecurity/falco-talon/internal/ttl"
 	"github.com/falcosecurity/falco-talon/utils"
 )
 
 const (
 	Name          string = "networkpolicy"
 	Category      string = "kubernetes"
-	Description   string = "Create, update a network policy to block all egress traffic for pod"
+	Description   string = "Create, update a network policy to block the egress and/or ingress traffic for pod"
 	Source        string = "syscalls"
 	Continue      bool   = true
 	UseContext    bool   = false
@@ -47,6 +57,8 @@ rules:
   - update
   - patch
   - create
+  - delete
+  - list
 - apiGroups:
   - apps
   resources:
@@ -81,6 +93,17 @@ rules:
     allow_namespaces:
       - "green-ns"
       - "blue-ns"
+    allow_pod_selectors:
+      - app: dns
+    allow_ports:
+      - port: 53
+        protocol: UDP
+    direction: both
+    ttl: 1h
+- action: Block the remote IP
+  actionner: kubernetes:networkpolicy
+  parameters:
+    deny_remote_ip: true
 `
 )
 
@@ -89,11 +112,44 @@ var (
 )
 
 type Parameters struct {
-	AllowCIDR       []string `mapstructure:"allow_cidr" validate:"omitempty"`
-	AllowNamespaces []string `mapstructure:"allow_namespaces" validate:"omitempty"`
+	AllowCIDR         []string            `mapstructure:"allow_cidr" validate:"omitempty"`
+	AllowNamespaces   []string            `mapstructure:"allow_namespaces" validate:"omitempty"`
+	AllowPodSelectors []map[string]string `mapstructure:"allow_pod_selectors" validate:"omitempty"`
+	AllowPorts        []Port              `mapstructure:"allow_ports" validate:"omitempty,dive"`
+	Direction         string              `mapstructure:"direction" validate:"omitempty,oneof=egress ingress both"`
+	TTL               string              `mapstructure:"ttl" validate:"omitempty"`
+	DenyRemoteIP      bool                `mapstructure:"deny_remote_ip" validate:"omitempty"`
+}
+
+// Port is a port, or a range of ports with end_port, allowed by the network policy
+type Port struct {
+	Protocol string `mapstructure:"protocol" validate:"omitempty"`
+	Port     int    `mapstructure:"port" validate:"required,min=1,max=65535"`
+	EndPort  int    `mapstructure:"end_port" validate:"omitempty,min=1,max=65535"`
 }
 
-const managedByStr string = "app.k8s.io/managed-by"
+const (
+	managedByStr string = "app.k8s.io/managed-by"
+	egressStr    string = "egress"
+	ingressStr   string = "ingress"
+	bothStr      string = "both"
+	anyIPv4      string = "0.0.0.0/0"
+	anyIPv6      string = "::/0"
+	// rulesRef is the entry of the references for the rules set by the isolations, ipRefPrefix the one for a denied IP
+	rulesRef    string = "rules"
+	ipRefPrefix string = "ip:"
+)
+
+// state is saved to revert the networkpolicy, a nil spec means it has been created.
+// The rules are the ones set by an isolation, the denied IP the one excluded by a deny_remote_ip action.
+type state struct {
+	Spec      *networkingv1.NetworkPolicySpec `json:"spec,omitempty"`
+	Rules     *networkingv1.NetworkPolicySpec `json:"rules,omitempty"`
+	Name      string                          `json:"name"`
+	Namespace string                          `json:"namespace"`
+	DeniedIP  string                          `json:"denied_ip,omitempty"`
+	TraceID   string                          `json:"trace_id,omitempty"`
+}
 
 type Actionner struct{}
 
@@ -122,16 +178,31 @@ func (a Actionner) Information() models.Information {
 }
 func (a Actionner) Parameters() models.Parameters {
 	return Parameters{
-		AllowCIDR:       []string{"0.0.0.0/0"},
-		AllowNamespaces: []string{},
+		AllowCIDR:         []string{anyIPv4},
+		AllowNamespaces:   []string{},
+		AllowPodSelectors: []map[string]string{},
+		AllowPorts:        []Port{},
+		Direction:         egressStr,
+		DenyRemoteIP:      false,
 	}
 }
 
-func (a Actionner) Checks(event *events.Event, _ *rules.Action) error {
-	return k8sChecks.CheckPodExist(event)
+func (a Actionner) Checks(event *events.Event, action *rules.Action) error {
+	if err := k8sChecks.CheckPodExist(event); err != nil {
+		return err
+	}
+
+	var parameters Parameters
+	if err := utils.DecodeParams(action.GetParameters(), &parameters); err != nil {
+		return err
+	}
+	if parameters.DenyRemoteIP {
+		return k8sChecks.CheckRemoteIP(event)
+	}
+	return nil
 }
 
-func (a Actionner) Run(event *events.Event, action *rules.Action) (utils.LogLine, *models.Data, error) {
+func (a Actionner) Run(ctx context.Context, event *events.Event, action *rules.Action) (utils.LogLine, *models.Data, error) {
 	podName := event.GetPodName()
 	namespace := event.GetNamespaceName()
 
@@ -151,7 +222,7 @@ func (a Actionner) Run(event *events.Event, action *rules.Action) (utils.LogLine
 		}, nil, err
 	}
 
-	pod, err := client.GetPod(podName, namespace)
+	pod, err := client.GetPod(ctx, podName, namespace)
 	if err != nil {
 		return utils.LogLine{
 				Objects: objects,
@@ -168,7 +239,7 @@ func (a Actionner) Run(event *events.Event, action *rules.Action) (utils.LogLine
 	if len(pod.OwnerReferences) != 0 {
 		switch pod.OwnerReferences[0].Kind {
 		case "DaemonSet":
-			u, err2 := client.GetDaemonsetFromPod(pod)
+			u, err2 := client.GetDaemonsetFromPod(ctx, pod)
 			if err2 != nil {
 				return utils.LogLine{
 						Objects: objects,
@@ -181,7 +252,7 @@ func (a Actionner) Run(event *events.Event, action *rules.Action) (utils.LogLine
 			owner = u.ObjectMeta.Name
 			labels = u.Spec.Selector.MatchLabels
 		case "StatefulSet":
-			u, err2 := client.GetStatefulsetFromPod(pod)
+			u, err2 := client.GetStatefulsetFromPod(ctx, pod)
 			if err2 != nil {
 				return utils.LogLine{
 						Objects: objects,
@@ -194,7 +265,7 @@ func (a Actionner) Run(event *events.Event, action *rules.Action) (utils.LogLine
 			owner = u.ObjectMeta.Name
 			labels = u.Spec.Selector.MatchLabels
 		case "ReplicaSet":
-			u, err2 := client.GetReplicasetFromPod(pod)
+			u, err2 := client.GetReplicasetFromPod(ctx, pod)
 			if err2 != nil {
 				return utils.LogLine{
 						Objects: objects,
@@ -242,15 +313,16 @@ func (a Actionner) Run(event *events.Event, action *rules.Action) (utils.LogLine
 			Labels:    labels,
 		},
 		Spec: networkingv1.NetworkPolicySpec{
-			PolicyTypes: []networkingv1.PolicyType{"Egress"},
 			PodSelector: metav1.LabelSelector{
 				MatchLabels: selector,
 			},
 		},
 	}
 
-	np, err := createEgressRule(&parameters)
-	if err != nil {
+	current, err := client.Clientset.NetworkingV1().NetworkPolicies(namespace).Get(ctx, owner, metav1.GetOptions{})
+	if errorsv1.IsNotFound(err) {
+		current = nil
+	} else if err != nil {
 		return utils.LogLine{
 				Objects: objects,
 				Error:   err.Error(),
@@ -260,19 +332,67 @@ func (a Actionner) Run(event *events.Event, action *rules.Action) (utils.LogLine
 			err
 	}
 
-	if np != nil {
-		payload.Spec.Egress = []networkingv1.NetworkPolicyEgressRule{*np}
+	direction := parameters.Direction
+	if direction == "" {
+		direction = egressStr
+	}
+	types := make([]networkingv1.PolicyType, 0, 2)
+	if direction == egressStr || direction == bothStr {
+		types = append(types, networkingv1.PolicyTypeEgress)
+	}
+	if direction == ingressStr || direction == bothStr {
+		types = append(types, networkingv1.PolicyTypeIngress)
+	}
+
+	var remoteIP string
+	if parameters.DenyRemoteIP {
+		remoteIP = event.GetRemoteIP()
+		objects["remote_ip"] = remoteIP
+		if err := checkRemoteIP(ctx, client, remoteIP); err != nil {
+			return utils.LogLine{
+					Objects: objects,
+					Error:   err.Error(),
+					Status:  utils.FailureStr,
+				},
+				nil,
+				err
+		}
 	}
 
 	objects["networkpolicy"] = owner
 
 	var output string
-	_, err = client.Clientset.NetworkingV1().NetworkPolicies(namespace).Get(context.Background(), owner, metav1.GetOptions{})
-	if errorsv1.IsNotFound(err) {
-		_, err = client.Clientset.NetworkingV1().NetworkPolicies(namespace).Create(context.Background(), &payload, metav1.CreateOptions{})
+	previous := state{Name: owner, Namespace: namespace, DeniedIP: remoteIP, TraceID: event.TraceID}
+	if current == nil {
+		if parameters.DenyRemoteIP {
+			denyIPs(&payload.Spec, []string{remoteIP}, types)
+		} else {
+			setRules(&payload.Spec, &parameters, nil, types)
+			previous.Rules = &payload.Spec
+		}
+		payload.ObjectMeta.Annotations = ttl.SetExpiration(payload.ObjectMeta.Labels, nil, true, parameters.TTL)
+		refs := make(map[string][]string)
+		track(refs, &previous, nil, &payload.Spec)
+		references.Set(&payload.ObjectMeta, refs)
+		_, err = client.Clientset.NetworkingV1().NetworkPolicies(namespace).Create(ctx, &payload, metav1.CreateOptions{})
 		output = fmt.Sprintf("the networkpolicy '%v' in the namespace '%v' has been created", owner, namespace)
 	} else {
-		_, err = client.Clientset.NetworkingV1().NetworkPolicies(namespace).Update(context.Background(), &payload, metav1.UpdateOptions{})
+		previous.Spec = &current.Spec
+		if parameters.DenyRemoteIP {
+			// the IP is only excluded from the existing rules, the policy must never allow more than before
+			payload.Spec = *current.Spec.DeepCopy()
+			denyIPs(&payload.Spec, []string{remoteIP}, types)
+		} else {
+			// the rules replace the existing ones, to be able to tighten them, the IPs denied before stay denied
+			setRules(&payload.Spec, &parameters, getDeniedIPs(&current.Spec, networkingv1.PolicyTypeEgress, networkingv1.PolicyTypeIngress), types)
+			previous.Rules = &payload.Spec
+		}
+		payload.ObjectMeta.ResourceVersion = current.ObjectMeta.ResourceVersion
+		payload.ObjectMeta.Annotations = ttl.SetExpiration(payload.ObjectMeta.Labels, current.ObjectMeta.Annotations, false, parameters.TTL)
+		refs := references.Get(current.ObjectMeta.Annotations)
+		track(refs, &previous, &current.Spec, &payload.Spec)
+		references.Set(&payload.ObjectMeta, refs)
+		_, err = client.Clientset.NetworkingV1().NetworkPolicies(namespace).Update(ctx, &payload, metav1.UpdateOptions{})
 		output = fmt.Sprintf("the networkpolicy '%v' in the namespace '%v' has been updated", owner, namespace)
 	}
 	if err != nil {
@@ -283,44 +403,378 @@ func (a Actionner) Run(event *events.Event, action *rules.Action) (utils.LogLine
 		}, nil, err
 	}
 
+	rollback, _ := json.Marshal(previous)
+
+	return utils.LogLine{
+		Objects: objects,
+		Output:  output,
+		Status:  utils.SuccessStr,
+	}, &models.Data{Rollback: rollback}, nil
+}
+
+func (a Actionner) Rollback(ctx context.Context, b []byte) (utils.LogLine, error) {
+	var previous state
+	if err := json.Unmarshal(b, &previous); err != nil {
+		return utils.LogLine{Status: utils.FailureStr, Error: err.Error()}, err
+	}
+
+	objects := map[string]string{
+		"networkpolicy": previous.Name,
+		"namespace":     previous.Namespace,
+	}
+	netpols := k8s.GetClient().Clientset.NetworkingV1().NetworkPolicies(previous.Namespace)
+
+	var output string
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		netpol, err := netpols.Get(ctx, previous.Name, metav1.GetOptions{})
+		if errorsv1.IsNotFound(err) {
+			output = fmt.Sprintf("the networkpolicy '%v' in the namespace '%v' doesn't exist anymore", previous.Name, previous.Namespace)
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		refs := references.Get(netpol.ObjectMeta.Annotations)
+		spec := revert(&netpol.Spec, &previous, refs)
+		if spec == nil {
+			output = fmt.Sprintf("the networkpolicy '%v' in the namespace '%v' has been kept, other events rely on its rules", previous.Name, previous.Namespace)
+			return nil
+		}
+		if previous.Spec == nil && len(spec.PolicyTypes) == 0 {
+			// the policy has been created by the action and nothing else relies on it
+			opts := metav1.DeleteOptions{Preconditions: &metav1.Preconditions{ResourceVersion: &netpol.ObjectMeta.ResourceVersion}}
+			if err := netpols.Delete(ctx, previous.Name, opts); err != nil && !errorsv1.IsNotFound(err) {
+				return err
+			}
+			output = fmt.Sprintf("the networkpolicy '%v' in the namespace '%v' has been deleted", previous.Name, previous.Namespace)
+			return nil
+		}
+		netpol.Spec = *spec
+		references.Set(&netpol.ObjectMeta, refs)
+		if _, err := netpols.Update(ctx, netpol, metav1.UpdateOptions{}); err != nil {
+			return err
+		}
+		output = fmt.Sprintf("the networkpolicy '%v' in the namespace '%v' has been restored", previous.Name, previous.Namespace)
+		return nil
+	})
+	if err != nil {
+		return utils.LogLine{
+			Objects: objects,
+			Error:   err.Error(),
+			Status:  utils.FailureStr,
+		}, err
+	}
+
 	return utils.LogLine{
 		Objects: objects,
 		Output:  output,
 		Status:  utils.SuccessStr,
-	}, nil, nil
+	}, nil
+}
+
+// revert returns the spec of the policy without what the action added, nil if other events still rely on it.
+// A denied IP is allowed again. The rules of an isolation are replaced by the previous ones, unless they've been
+// replaced since, the IPs denied by the other events stay denied.
+func revert(spec *networkingv1.NetworkPolicySpec, previous *state, refs map[string][]string) *networkingv1.NetworkPolicySpec {
+	if previous.DeniedIP != "" {
+		entry := ipRefPrefix + previous.DeniedIP
+		_, tracked := refs[entry]
+		if !references.Release(refs, entry, previous.TraceID) && tracked {
+			return nil
+		}
+		reverted := spec.DeepCopy()
+		for _, t := range reverted.PolicyTypes {
+			allowIPs(reverted, []string{previous.DeniedIP}, t)
+		}
+		_, isolated := refs[rulesRef]
+		if previous.Spec == nil && !isolated && len(getDeniedIPs(reverted, reverted.PolicyTypes...)) == 0 {
+			return &networkingv1.NetworkPolicySpec{PodSelector: spec.PodSelector}
+		}
+		return reverted
+	}
+
+	_, tracked := refs[rulesRef]
+	released := references.Release(refs, rulesRef, previous.TraceID)
+	if !released && (tracked || previous.Rules != nil && !sameRules(spec, previous.Rules)) {
+		return nil
+	}
+	reverted := &networkingv1.NetworkPolicySpec{PodSelector: spec.PodSelector}
+	if previous.Spec != nil {
+		reverted = previous.Spec.DeepCopy()
+	}
+	for _, t := range []networkingv1.PolicyType{networkingv1.PolicyTypeEgress, networkingv1.PolicyTypeIngress} {
+		denied := getDeniedIPs(spec, t)
+		if slices.Contains(reverted.PolicyTypes, t) {
+			stale := slices.DeleteFunc(getDeniedIPs(reverted, t), func(i string) bool { return slices.Contains(denied, i) })
+			allowIPs(reverted, stale, t)
+		}
+		if len(denied) != 0 {
+			denyIPs(reverted, denied, []networkingv1.PolicyType{t})
+		}
+	}
+	return reverted
+}
+
+// track records the event in the references of the entry of the policy it relies on, the denied IP or the
+// rules. An entry set before by someone else isn't tracked, new rules replace the ones of the previous events.
+func track(refs map[string][]string, previous *state, current, updated *networkingv1.NetworkPolicySpec) {
+	entry := rulesRef
+	if previous.DeniedIP != "" {
+		entry = ipRefPrefix + previous.DeniedIP
+	}
+	_, tracked := refs[entry]
+	changed := current == nil || !equality.Semantic.DeepEqual(*current, *updated)
+	if previous.DeniedIP == "" && changed {
+		delete(refs, entry)
+	}
+	if changed || tracked {
+		references.Add(refs, entry, previous.TraceID)
+	}
+}
+
+// sameRules returns true if the rules of the policies are the same, apart from the denied IPs
+func sameRules(a, b *networkingv1.NetworkPolicySpec) bool {
+	x, y := a.DeepCopy(), b.DeepCopy()
+	for _, spec := range []*networkingv1.NetworkPolicySpec{x, y} {
+		for _, t := range []networkingv1.PolicyType{networkingv1.PolicyTypeEgress, networkingv1.PolicyTypeIngress} {
+			for _, i := range getPeers(spec, t) {
+				if i.IPBlock != nil {
+					i.IPBlock.Except = nil
+				}
+			}
+		}
+	}
+	return equality.Semantic.DeepEqual(x, y)
 }
 
-func createEgressRule(parameters *Parameters) (*networkingv1.NetworkPolicyEgressRule, error) {
-	if len(parameters.AllowCIDR) == 0 && len(parameters.AllowNamespaces) == 0 {
-		return nil, nil
+// Reconcile deletes the networkpolicies with an expired ttl
+func (a Actionner) Reconcile(ctx context.Context) ([]utils.LogLine, error) {
+	client := k8s.GetClient()
+	netpols, err := client.Clientset.NetworkingV1().NetworkPolicies("").List(ctx, metav1.ListOptions{LabelSelector: ttl.Selector()})
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]utils.LogLine, 0)
+	for _, i := range netpols.Items {
+		if !ttl.IsExpired(i.ObjectMeta.Annotations[ttl.ExpiresAtAnnotation]) {
+			continue
+		}
+		log := utils.LogLine{
+			Objects: map[string]string{
+				"networkpolicy": i.ObjectMeta.Name,
+				"namespace":     i.ObjectMeta.Namespace,
+			},
+		}
+		err := client.Clientset.NetworkingV1().NetworkPolicies(i.ObjectMeta.Namespace).Delete(ctx, i.ObjectMeta.Name, metav1.DeleteOptions{})
+		if err != nil && !errorsv1.IsNotFound(err) {
+			log.Status = utils.FailureStr
+			log.Error = err.Error()
+		} else {
+			log.Status = utils.SuccessStr
+			log.Output = fmt.Sprintf("the networkpolicy '%v' in the namespace '%v' has expired and has been deleted", i.ObjectMeta.Name, i.ObjectMeta.Namespace)
+		}
+		results = append(results, log)
 	}
+	return results, nil
+}
 
+// createPeers returns the peers allowed by the network policy
+func createPeers(parameters *Parameters) []networkingv1.NetworkPolicyPeer {
 	np := make([]networkingv1.NetworkPolicyPeer, 0)
-	if allowedCidr := parameters.AllowCIDR; len(allowedCidr) != 0 {
-		for _, i := range allowedCidr {
-			np = append(np,
-				networkingv1.NetworkPolicyPeer{
-					IPBlock: &networkingv1.IPBlock{
-						CIDR: i,
+
+	for _, i := range parameters.AllowCIDR {
+		np = append(np, networkingv1.NetworkPolicyPeer{IPBlock: &networkingv1.IPBlock{CIDR: i}})
+	}
+	for _, i := range parameters.AllowNamespaces {
+		np = append(np,
+			networkingv1.NetworkPolicyPeer{
+				NamespaceSelector: &metav1.LabelSelector{
+					MatchLabels: map[string]string{
+						"k8s.io/metadata.name": i,
 					},
 				},
-			)
-		}
+			},
+		)
 	}
-	if allowedNamespaces := parameters.AllowNamespaces; len(allowedNamespaces) != 0 {
-		for _, i := range allowedNamespaces {
-			np = append(np,
-				networkingv1.NetworkPolicyPeer{
-					NamespaceSelector: &metav1.LabelSelector{
-						MatchLabels: map[string]string{
-							"k8s.io/metadata.name": i,
-						},
-					},
+	for _, i := range parameters.AllowPodSelectors {
+		np = append(np,
+			networkingv1.NetworkPolicyPeer{
+				PodSelector: &metav1.LabelSelector{
+					MatchLabels: i,
 				},
-			)
+			},
+		)
+	}
+	return np
+}
+
+// setRules sets the rules allowed by the parameters for the directions, the denied IPs are excluded from their CIDRs
+func setRules(spec *networkingv1.NetworkPolicySpec, parameters *Parameters, denied []string, types []networkingv1.PolicyType) {
+	spec.PolicyTypes = types
+	spec.Egress = nil
+	spec.Ingress = nil
+
+	peers := createPeers(parameters)
+	ports := createPorts(parameters)
+	if len(peers) == 0 && len(ports) == 0 {
+		return
+	}
+	for _, i := range peers {
+		if i.IPBlock != nil {
+			excludeIPs(i.IPBlock, denied)
+		}
+	}
+	if slices.Contains(types, networkingv1.PolicyTypeEgress) {
+		spec.Egress = []networkingv1.NetworkPolicyEgressRule{{To: peers, Ports: ports}}
+	}
+	if slices.Contains(types, networkingv1.PolicyTypeIngress) {
+		spec.Ingress = []networkingv1.NetworkPolicyIngressRule{{From: peers, Ports: ports}}
+	}
+}
+
+// denyIPs denies the IPs for the directions, they're excluded from the ipBlocks of the existing rules; a direction
+// not restricted yet by the policy gets a rule allowing all but the IPs. The traffic in the cluster stays
+// allowed, the ipBlocks don't apply to the pods for some CNIs, checkRemoteIP rejects the IPs of the pods
+func denyIPs(spec *networkingv1.NetworkPolicySpec, denied []string, types []networkingv1.PolicyType) {
+	for _, t := range types {
+		if slices.Contains(spec.PolicyTypes, t) {
+			for _, i := range getPeers(spec, t) {
+				if i.IPBlock != nil {
+					excludeIPs(i.IPBlock, denied)
+				}
+			}
+			continue
+		}
+
+		peers := []networkingv1.NetworkPolicyPeer{{NamespaceSelector: &metav1.LabelSelector{}}}
+		cidrs := []string{anyIPv4}
+		if slices.ContainsFunc(denied, func(ip string) bool { return net.ParseIP(ip).To4() == nil }) {
+			cidrs = append(cidrs, anyIPv6)
+		}
+		for _, i := range cidrs {
+			block := &networkingv1.IPBlock{CIDR: i}
+			excludeIPs(block, denied)
+			peers = append(peers, networkingv1.NetworkPolicyPeer{IPBlock: block})
+		}
+		spec.PolicyTypes = append(spec.PolicyTypes, t)
+		if t == networkingv1.PolicyTypeEgress {
+			spec.Egress = append(spec.Egress, networkingv1.NetworkPolicyEgressRule{To: peers})
+		} else {
+			spec.Ingress = append(spec.Ingress, networkingv1.NetworkPolicyIngressRule{From: peers})
+		}
+	}
+}
+
+// getPeers returns the peers of the rules of the policy for a direction, their ipBlocks are shared with the policy
+func getPeers(spec *networkingv1.NetworkPolicySpec, t networkingv1.PolicyType) []networkingv1.NetworkPolicyPeer {
+	peers := make([]networkingv1.NetworkPolicyPeer, 0)
+	if t == networkingv1.PolicyTypeEgress {
+		for _, i := range spec.Egress {
+			peers = append(peers, i.To...)
+		}
+		return peers
+	}
+	for _, i := range spec.Ingress {
+		peers = append(peers, i.From...)
+	}
+	return peers
+}
+
+// checkRemoteIP returns an error if the IP is the one of a pod, the deny_remote_ip mode allows the traffic in
+// the cluster and the ipBlocks don't apply to the pods for some CNIs, the IP wouldn't be denied
+func checkRemoteIP(ctx context.Context, client *k8s.Client, ip string) error {
+	pods, err := client.Clientset.CoreV1().Pods("").List(ctx, metav1.ListOptions{FieldSelector: "status.podIP=" + ip})
+	if err != nil {
+		return err
+	}
+	for _, i := range pods.Items {
+		if !i.Spec.HostNetwork {
+			return fmt.Errorf("the remote IP '%v' is the one of the pod '%v' in the namespace '%v', it can't be denied with an ipBlock, isolate the pod instead", ip, i.Name, i.Namespace)
+		}
+	}
+	return nil
+}
+
+// excludeIPs adds the denied IPs within the CIDR of the block to its exceptions
+func excludeIPs(block *networkingv1.IPBlock, denied []string) {
+	_, cidr, err := net.ParseCIDR(block.CIDR)
+	if err != nil {
+		return
+	}
+	for _, i := range denied {
+		ip := net.ParseIP(i)
+		if ip == nil || !cidr.Contains(ip) {
+			continue
+		}
+		if except := toCIDR(ip); !slices.Contains(block.Except, except) {
+			block.Except = append(block.Except, except)
+		}
+	}
+}
+
+// allowIPs removes the exceptions of the IPs from the ipBlocks of the rules of the direction
+func allowIPs(spec *networkingv1.NetworkPolicySpec, ips []string, t networkingv1.PolicyType) {
+	excepts := make([]string, 0, len(ips))
+	for _, i := range ips {
+		if ip := net.ParseIP(i); ip != nil {
+			excepts = append(excepts, toCIDR(ip))
+		}
+	}
+	for _, i := range getPeers(spec, t) {
+		if i.IPBlock != nil {
+			i.IPBlock.Except = slices.DeleteFunc(i.IPBlock.Except, func(e string) bool { return slices.Contains(excepts, e) })
+		}
+	}
+}
+
+// toCIDR returns the CIDR of a single IP, with a /32 or a /128 mask
+func toCIDR(ip net.IP) string {
+	if ip.To4() != nil {
+		return ip.String() + "/32"
+	}
+	return ip.String() + "/128"
+}
+
+// createPorts returns the ports allowed by the network policy, all the ports are allowed if none is set
+func createPorts(parameters *Parameters) []networkingv1.NetworkPolicyPort {
+	ports := make([]networkingv1.NetworkPolicyPort, 0, len(parameters.AllowPorts))
+	for _, i := range parameters.AllowPorts {
+		protocol := corev1.ProtocolTCP
+		if i.Protocol != "" {
+			protocol = corev1.Protocol(strings.ToUpper(i.Protocol))
+		}
+		port := intstr.FromInt32(int32(i.Port)) // #nosec G115 the port is validated
+		p := networkingv1.NetworkPolicyPort{
+			Protocol: &protocol,
+			Port:     &port,
+		}
+		if i.EndPort != 0 {
+			endPort := int32(i.EndPort) // #nosec G115 the port is validated
+			p.EndPort = &endPort
+		}
+		ports = append(ports, p)
+	}
+	return ports
+}
+
+// getDeniedIPs returns the single IPs excluded from the allowed CIDRs of the network policy for the directions
+func getDeniedIPs(spec *networkingv1.NetworkPolicySpec, types ...networkingv1.PolicyType) []string {
+	denied := make([]string, 0)
+	for _, t := range types {
+		for _, i := range getPeers(spec, t) {
+			if i.IPBlock == nil {
+				continue
+			}
+			for _, j := range i.IPBlock.Except {
+				if ip, n, err := net.ParseCIDR(j); err == nil && toCIDR(ip) == n.String() {
+					denied = append(denied, ip.String())
+				}
+			}
 		}
 	}
-	return &networkingv1.NetworkPolicyEgressRule{To: np}, nil
+	return utils.Deduplicate(denied)
 }
 
 func (a Actionner) CheckParameters(action *rules.Action) error {
@@ -337,6 +791,27 @@ func (a Actionner) CheckParameters(action *rules.Action) error {
 		}
 	}
 
+	for _, i := range parameters.AllowPorts {
+		switch strings.ToUpper(i.Protocol) {
+		case "", string(corev1.ProtocolTCP), string(corev1.ProtocolUDP), string(corev1.ProtocolSCTP):
+		default:
+			return fmt.Errorf("wrong protocol '%v' for the port '%v'", i.Protocol, i.Port)
+		}
+		if i.EndPort != 0 && i.EndPort < i.Port {
+			return fmt.Errorf("wrong range of ports '%v-%v'", i.Port, i.EndPort)
+		}
+	}
+
+	for _, i := range parameters.AllowPodSelectors {
+		if len(i) == 0 {
+			return errors.New("the pod selectors can't be empty")
+		}
+	}
+
+	if err := ttl.Check(parameters.TTL); err != nil {
+		return err
+	}
+
 	err = utils.ValidateStruct(parameters)
 	if err != nil {
 		return err
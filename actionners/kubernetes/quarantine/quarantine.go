@@ -0,0 +1,425 @@
+package quarantine
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	errorsv1 "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	"github.com/falcosecurity/falco-talon/internal/events"
+	k8sChecks "github.com/falcosecurity/falco-talon/internal/kubernetes/checks"
+	k8s "github.com/falcosecurity/falco-talon/internal/kubernetes/client"
+	"github.com/falcosecurity/falco-talon/internal/models"
+	"github.com/falcosecurity/falco-talon/internal/rules"
+	"github.com/falcosecurity/falco-talon/utils"
+)
+
+const (
+	Name          string = "quarantine"
+	Category      string = "kubernetes"
+	Description   string = "Detach the pod from its controller and its services and isolate it with a deny-all network policy, for a forensic investigation"
+	Source        string = "syscalls"
+	Continue      bool   = true
+	UseContext    bool   = false
+	AllowOutput   bool   = false
+	RequireOutput bool   = false
+	Permissions   string = `apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
+metadata:
+  name: falco-talon
+rules:
+- apiGroups:
+  - ""
+  resources:
+  - pods
+  verbs:
+  - get
+  - patch
+  - list
+- apiGroups:
+  - ""
+  resources:
+  - services
+  verbs:
+  - list
+- apiGroups:
+  - networking.k8s.io
+  resources:
+  - networkpolicies
+  verbs:
+  - get
+  - create
+  - update
+  - delete
+- apiGroups:
+  - apps
+  resources:
+  - daemonsets
+  verbs:
+  - get
+- apiGroups:
+  - apps
+  resources:
+  - deployments
+  verbs:
+  - get
+- apiGroups:
+  - apps
+  resources:
+  - replicasets
+  verbs:
+  - get
+`
+	Example string = `- action: Quarantine the pod
+  actionner: kubernetes:quarantine
+  parameters:
+    label: falco-talon.falcosecurity.org/quarantine
+`
+)
+
+var (
+	RequiredOutputFields = []string{"k8s.ns.name", "k8s.pod.name"}
+)
+
+type Parameters struct {
+	Label string `mapstructure:"label" validate:"omitempty"`
+}
+
+const (
+	defaultLabel     string = "falco-talon.falcosecurity.org/quarantine"
+	networkPolicyStr string = "falco-talon-quarantine"
+	managedByStr     string = "app.k8s.io/managed-by"
+	trueStr          string = "true"
+)
+
+// state is saved to revert the quarantine of the pod
+type state struct {
+	Labels    map[string]*string `json:"labels"`
+	Pod       string             `json:"pod"`
+	Namespace string             `json:"namespace"`
+	Label     string             `json:"label"`
+}
+
+type Actionner struct{}
+
+func Register() *Actionner {
+	return new(Actionner)
+}
+
+func (a Actionner) Init() error {
+	return k8s.Init()
+}
+
+func (a Actionner) Information() models.Information {
+	return models.Information{
+		Name:                 Name,
+		FullName:             Category + ":" + Name,
+		Category:             Category,
+		Description:          Description,
+		Source:               Source,
+		RequiredOutputFields: RequiredOutputFields,
+		Permissions:          Permissions,
+		Example:              Example,
+		Continue:             Continue,
+		AllowOutput:          AllowOutput,
+		RequireOutput:        RequireOutput,
+	}
+}
+func (a Actionner) Parameters() models.Parameters {
+	return Parameters{
+		Label: defaultLabel,
+	}
+}
+
+func (a Actionner) Checks(event *events.Event, _ *rules.Action) error {
+	return k8sChecks.CheckPodExist(event)
+}
+
+func (a Actionner) Run(ctx context.Context, event *events.Event, action *rules.Action) (utils.LogLine, *models.Data, error) {
+	podName := event.GetPodName()
+	namespace := event.GetNamespaceName()
+
+	objects := map[string]string{
+		"pod":       podName,
+		"namespace": namespace,
+	}
+	client := k8s.GetClient()
+
+	var parameters Parameters
+	err := utils.DecodeParams(action.GetParameters(), &parameters)
+	if err != nil {
+		return utils.LogLine{
+			Objects: nil,
+			Error:   err.Error(),
+			Status:  utils.FailureStr,
+		}, nil, err
+	}
+	if parameters.Label == "" {
+		parameters.Label = defaultLabel
+	}
+
+	pod, err := client.GetPod(ctx, podName, namespace)
+	if err != nil {
+		return utils.LogLine{
+			Objects: objects,
+			Error:   err.Error(),
+			Status:  utils.FailureStr,
+		}, nil, err
+	}
+
+	keys, err := getSelectorKeys(ctx, client, pod, objects)
+	if err != nil {
+		return utils.LogLine{
+			Objects: objects,
+			Error:   err.Error(),
+			Status:  utils.FailureStr,
+		}, nil, err
+	}
+
+	// the labels selected by the services are removed too, to stop the traffic to the pod
+	services, err := client.Clientset.CoreV1().Services(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return utils.LogLine{
+			Objects: objects,
+			Error:   err.Error(),
+			Status:  utils.FailureStr,
+		}, nil, err
+	}
+	matched := make([]string, 0)
+	for _, i := range services.Items {
+		if len(i.Spec.Selector) == 0 || !labels.SelectorFromSet(i.Spec.Selector).Matches(labels.Set(pod.Labels)) {
+			continue
+		}
+		matched = append(matched, i.Name)
+		for j := range i.Spec.Selector {
+			keys = append(keys, j)
+		}
+	}
+	if len(matched) != 0 {
+		slices.Sort(matched)
+		objects["services"] = strings.Join(matched, ",")
+	}
+
+	previous := state{
+		Pod:       podName,
+		Namespace: namespace,
+		Label:     parameters.Label,
+		Labels:    make(map[string]*string),
+	}
+	patch := map[string]*string{}
+	for _, i := range utils.Deduplicate(keys) {
+		v, ok := pod.Labels[i]
+		if !ok || i == parameters.Label {
+			continue
+		}
+		previous.Labels[i] = &v
+		patch[i] = nil
+	}
+	rollback, _ := json.Marshal(previous)
+
+	// the pod is isolated before being detached from its controller and its services, it's never reachable
+	// without the policy; the state is returned on a failure, to revert the steps already done
+	objects["networkpolicy"] = networkPolicyStr
+	if err := createNetworkPolicy(ctx, client, namespace, parameters.Label); err != nil {
+		return utils.LogLine{
+			Objects: objects,
+			Error:   err.Error(),
+			Status:  utils.FailureStr,
+		}, nil, err
+	}
+
+	quarantined := trueStr
+	for _, i := range []map[string]*string{{parameters.Label: &quarantined}, patch} {
+		if len(i) == 0 {
+			continue
+		}
+		payload, _ := json.Marshal(map[string]any{"metadata": map[string]any{"labels": i}})
+		_, err = client.Clientset.CoreV1().Pods(namespace).Patch(ctx, podName, types.MergePatchType, payload, metav1.PatchOptions{})
+		if err != nil {
+			return utils.LogLine{
+				Objects: objects,
+				Error:   err.Error(),
+				Status:  utils.FailureStr,
+			}, &models.Data{Rollback: rollback}, err
+		}
+	}
+
+	return utils.LogLine{
+		Objects: objects,
+		Output:  fmt.Sprintf("the pod '%v' in the namespace '%v' has been quarantined", podName, namespace),
+		Status:  utils.SuccessStr,
+	}, &models.Data{Rollback: rollback}, nil
+}
+
+// getSelectorKeys returns the keys of the labels used by the controller of the pod to select it,
+// removing them detaches the pod and the controller creates a replacement; the pods of the statefulsets
+// are rejected, their ordinal is still taken by the detached pod
+func getSelectorKeys(ctx context.Context, client *k8s.Client, pod *corev1.Pod, objects map[string]string) ([]string, error) {
+	var selector *metav1.LabelSelector
+	if len(pod.OwnerReferences) == 0 {
+		return []string{}, nil
+	}
+	switch pod.OwnerReferences[0].Kind {
+	case utils.ReplicaSetStr:
+		u, err := client.GetReplicasetFromPod(ctx, pod)
+		if err != nil {
+			return nil, err
+		}
+		objects["replicaset"] = u.ObjectMeta.Name
+		selector = u.Spec.Selector
+		if d, err := client.GetDeploymentFromPod(ctx, pod); err == nil {
+			objects["deployment"] = d.ObjectMeta.Name
+		}
+	case utils.DaemonSetStr:
+		u, err := client.GetDaemonsetFromPod(ctx, pod)
+		if err != nil {
+			return nil, err
+		}
+		objects["daemonset"] = u.ObjectMeta.Name
+		selector = u.Spec.Selector
+	case utils.StatefulSetStr:
+		// the replacement would have the same name as the detached pod, the statefulset can't create it
+		return nil, fmt.Errorf("the pod '%v' in the namespace '%v' belongs to a statefulset, its replacement can't be created while it's quarantined", pod.Name, pod.Namespace)
+	default:
+		return nil, fmt.Errorf("the controller '%v' of the pod '%v' in the namespace '%v' is not supported", pod.OwnerReferences[0].Kind, pod.Name, pod.Namespace)
+	}
+
+	keys := make([]string, 0)
+	if selector == nil {
+		return keys, nil
+	}
+	for i := range selector.MatchLabels {
+		keys = append(keys, i)
+	}
+	for _, i := range selector.MatchExpressions {
+		keys = append(keys, i.Key)
+	}
+	return keys, nil
+}
+
+// createNetworkPolicy creates the deny-all network policy for the quarantined pods of the namespace, an existing
+// policy is updated if it doesn't select the pods with the label or doesn't deny all their traffic
+func createNetworkPolicy(ctx context.Context, client *k8s.Client, namespace, label string) error {
+	spec := networkingv1.NetworkPolicySpec{
+		PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress, networkingv1.PolicyTypeEgress},
+		PodSelector: metav1.LabelSelector{
+			MatchLabels: map[string]string{label: trueStr},
+		},
+	}
+
+	netpol, err := client.Clientset.NetworkingV1().NetworkPolicies(namespace).Get(ctx, networkPolicyStr, metav1.GetOptions{})
+	if err == nil {
+		if equality.Semantic.DeepEqual(netpol.Spec, spec) {
+			return nil
+		}
+		netpol.Spec = spec
+		_, err = client.Clientset.NetworkingV1().NetworkPolicies(namespace).Update(ctx, netpol, metav1.UpdateOptions{})
+		return err
+	}
+	if !errorsv1.IsNotFound(err) {
+		return err
+	}
+
+	payload := networkingv1.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      networkPolicyStr,
+			Namespace: namespace,
+			Labels: map[string]string{
+				managedByStr: utils.FalcoTalonStr,
+			},
+		},
+		Spec: spec,
+	}
+	_, err = client.Clientset.NetworkingV1().NetworkPolicies(namespace).Create(ctx, &payload, metav1.CreateOptions{})
+	if errorsv1.IsAlreadyExists(err) {
+		// created meanwhile by a concurrent action, it's checked again
+		return createNetworkPolicy(ctx, client, namespace, label)
+	}
+	return err
+}
+
+func (a Actionner) Rollback(ctx context.Context, b []byte) (utils.LogLine, error) {
+	var previous state
+	if err := json.Unmarshal(b, &previous); err != nil {
+		return utils.LogLine{Status: utils.FailureStr, Error: err.Error()}, err
+	}
+
+	objects := map[string]string{
+		"pod":       previous.Pod,
+		"namespace": previous.Namespace,
+	}
+	client := k8s.GetClient()
+
+	patch := previous.Labels
+	if patch == nil {
+		patch = make(map[string]*string)
+	}
+	patch[previous.Label] = nil
+	payload, _ := json.Marshal(map[string]any{"metadata": map[string]any{"labels": patch}})
+	_, err := client.Clientset.CoreV1().Pods(previous.Namespace).Patch(ctx, previous.Pod, types.MergePatchType, payload, metav1.PatchOptions{})
+	if err != nil && !errorsv1.IsNotFound(err) {
+		return utils.LogLine{
+			Objects: objects,
+			Error:   err.Error(),
+			Status:  utils.FailureStr,
+		}, err
+	}
+
+	// the network policy is kept while other pods of the namespace are quarantined
+	pods, err := client.Clientset.CoreV1().Pods(previous.Namespace).List(ctx, metav1.ListOptions{LabelSelector: previous.Label + "=" + trueStr})
+	if err != nil {
+		return utils.LogLine{
+			Objects: objects,
+			Error:   err.Error(),
+			Status:  utils.FailureStr,
+		}, err
+	}
+	if len(pods.Items) == 0 {
+		objects["networkpolicy"] = networkPolicyStr
+		err := client.Clientset.NetworkingV1().NetworkPolicies(previous.Namespace).Delete(ctx, networkPolicyStr, metav1.DeleteOptions{})
+		if err != nil && !errorsv1.IsNotFound(err) {
+			return utils.LogLine{
+				Objects: objects,
+				Error:   err.Error(),
+				Status:  utils.FailureStr,
+			}, err
+		}
+	}
+
+	return utils.LogLine{
+		Objects: objects,
+		Output:  fmt.Sprintf("the pod '%v' in the namespace '%v' has been released from the quarantine", previous.Pod, previous.Namespace),
+		Status:  utils.SuccessStr,
+	}, nil
+}
+
+func (a Actionner) CheckParameters(action *rules.Action) error {
+	var parameters Parameters
+	err := utils.DecodeParams(action.GetParameters(), &parameters)
+	if err != nil {
+		return err
+	}
+
+	err = utils.ValidateStruct(parameters)
+	if err != nil {
+		return err
+	}
+
+	if parameters.Label != "" {
+		if errs := validation.IsQualifiedName(parameters.Label); len(errs) != 0 {
+			return fmt.Errorf("wrong label '%v': %v", parameters.Label, strings.Join(errs, ", "))
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,437 @@
+package scale
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/falcosecurity/falco-talon/internal/events"
+	k8sChecks "github.com/falcosecurity/falco-talon/internal/kubernetes/checks"
+	k8s "github.com/falcosecurity/falco-talon/internal/kubernetes/client"
+	"github.com/falcosecurity/falco-talon/internal/models"
+	"github.com/falcosecurity/falco-talon/internal/rules"
+	"github.com/falcosecurity/falco-talon/internal/ttl"
+	"github.com/falcosecurity/falco-talon/utils"
+)
+
+const (
+	Name          string = "scale"
+	Category      string = "kubernetes"
+	Description   string = "Scale the Deployment or the StatefulSet of the pod"
+	Source        string = "syscalls"
+	Continue      bool   = false
+	UseContext    bool   = false
+	AllowOutput   bool   = false
+	RequireOutput bool   = false
+	Permissions   string = `apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
+metadata:
+  name: falco-talon
+rules:
+- apiGroups:
+  - ""
+  resources:
+  - pods
+  verbs:
+  - get
+  - list
+- apiGroups:
+  - apps
+  resources:
+  - replicasets
+  verbs:
+  - get
+- apiGroups:
+  - apps
+  resources:
+  - deployments
+  - statefulsets
+  verbs:
+  - get
+  - patch
+  - list
+`
+	Example string = `- action: Scale down the workload
+  actionner: kubernetes:scale
+  parameters:
+    replicas: 0
+    ignore_daemonsets: true
+    ignore_statefulsets: true
+    ttl: 1h
+`
+)
+
+var (
+	RequiredOutputFields = []string{"k8s.ns.name", "k8s.pod.name"}
+)
+
+type Parameters struct {
+	TTL                string `mapstructure:"ttl" validate:"omitempty"`
+	Replicas           int    `mapstructure:"replicas" validate:"gte=0,lte=2147483647"`
+	IgnoreDaemonsets   bool   `mapstructure:"ignore_daemonsets" validate:"omitempty"`
+	IgnoreStatefulSets bool   `mapstructure:"ignore_statefulsets" validate:"omitempty"`
+}
+
+// PreviousReplicasAnnotation is the number of replicas of the workload before its first scaling by Falco Talon
+const PreviousReplicasAnnotation string = "falco-talon.falcosecurity.org/previous-replicas"
+
+const (
+	deploymentStr  string = "deployment"
+	statefulsetStr string = "statefulset"
+)
+
+// state is saved to revert the scaling, 'Scaled' means the workload was already scaled by Falco Talon
+type state struct {
+	Kind      string `json:"kind"`
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	Replicas  int32  `json:"replicas"`
+	Scaled    bool   `json:"scaled"`
+}
+
+type Actionner struct{}
+
+func Register() *Actionner {
+	return new(Actionner)
+}
+
+func (a Actionner) Init() error {
+	return k8s.Init()
+}
+
+func (a Actionner) Information() models.Information {
+	return models.Information{
+		Name:                 Name,
+		FullName:             Category + ":" + Name,
+		Category:             Category,
+		Description:          Description,
+		Source:               Source,
+		RequiredOutputFields: RequiredOutputFields,
+		Permissions:          Permissions,
+		Example:              Example,
+		Continue:             Continue,
+		AllowOutput:          AllowOutput,
+		RequireOutput:        RequireOutput,
+	}
+}
+func (a Actionner) Parameters() models.Parameters {
+	return Parameters{
+		Replicas:           0,
+		IgnoreDaemonsets:   false,
+		IgnoreStatefulSets: false,
+	}
+}
+
+func (a Actionner) Checks(event *events.Event, _ *rules.Action) error {
+	return k8sChecks.CheckPodExist(event)
+}
+
+func (a Actionner) Run(ctx context.Context, event *events.Event, action *rules.Action) (utils.LogLine, *models.Data, error) {
+	podName := event.GetPodName()
+	namespace := event.GetNamespaceName()
+
+	objects := map[string]string{
+		"pod":       podName,
+		"namespace": namespace,
+	}
+
+	var parameters Parameters
+	err := utils.DecodeParams(action.GetParameters(), &parameters)
+	if err != nil {
+		return utils.LogLine{
+			Objects: nil,
+			Error:   err.Error(),
+			Status:  utils.FailureStr,
+		}, nil, err
+	}
+
+	client := k8s.GetClient()
+	pod, err := client.GetPod(ctx, podName, namespace)
+	if err != nil {
+		return utils.LogLine{
+			Objects: objects,
+			Error:   err.Error(),
+			Status:  utils.FailureStr,
+		}, nil, err
+	}
+
+	ownerKind, err := k8s.GetOwnerKind(*pod)
+	if err != nil {
+		return utils.LogLine{
+			Objects: objects,
+			Error:   err.Error(),
+			Status:  utils.FailureStr,
+		}, nil, err
+	}
+
+	var kind, name string
+	var replicas *int32
+	var annotations map[string]string
+
+	switch ownerKind {
+	case utils.DaemonSetStr:
+		if parameters.IgnoreDaemonsets {
+			return utils.LogLine{
+				Objects: objects,
+				Status:  "ignored",
+				Result:  fmt.Sprintf("the pod '%v' in the namespace '%v' belongs to a DaemonSet and will be ignored.", podName, namespace),
+			}, nil, nil
+		}
+		err2 := fmt.Errorf("the pod '%v' in the namespace '%v' belongs to a DaemonSet which can't be scaled", podName, namespace)
+		return utils.LogLine{
+			Objects: objects,
+			Error:   err2.Error(),
+			Status:  utils.FailureStr,
+		}, nil, err2
+	case utils.StatefulSetStr:
+		if parameters.IgnoreStatefulSets {
+			return utils.LogLine{
+				Objects: objects,
+				Status:  "ignored",
+				Result:  fmt.Sprintf("the pod '%v' in the namespace '%v' belongs to a StatefulSet and will be ignored.", podName, namespace),
+			}, nil, nil
+		}
+		u, err2 := client.GetStatefulsetFromPod(ctx, pod)
+		if err2 != nil {
+			return utils.LogLine{
+				Objects: objects,
+				Error:   err2.Error(),
+				Status:  utils.FailureStr,
+			}, nil, err2
+		}
+		kind, name, replicas, annotations = statefulsetStr, u.ObjectMeta.Name, u.Spec.Replicas, u.ObjectMeta.Annotations
+	case utils.ReplicaSetStr:
+		u, err2 := client.GetDeploymentFromPod(ctx, pod)
+		if err2 != nil {
+			return utils.LogLine{
+				Objects: objects,
+				Error:   err2.Error(),
+				Status:  utils.FailureStr,
+			}, nil, err2
+		}
+		kind, name, replicas, annotations = deploymentStr, u.ObjectMeta.Name, u.Spec.Replicas, u.ObjectMeta.Annotations
+	default:
+		err2 := fmt.Errorf("the pod '%v' in the namespace '%v' belongs to a %v which can't be scaled", podName, namespace, ownerKind)
+		return utils.LogLine{
+			Objects: objects,
+			Error:   err2.Error(),
+			Status:  utils.FailureStr,
+		}, nil, err2
+	}
+
+	objects[kind] = name
+
+	// the number of replicas defaults to 1 when it's not set
+	current := int32(1)
+	if replicas != nil {
+		current = *replicas
+	}
+	target := int32(parameters.Replicas) // #nosec G115 the value is validated
+
+	// the previous number of replicas is recorded at the first scaling only, to restore the original value
+	previous, scaled := annotations[PreviousReplicasAnnotation]
+	if !scaled {
+		if current == target {
+			return utils.LogLine{
+				Objects: objects,
+				Output:  fmt.Sprintf("the %v '%v' in the namespace '%v' already has %v replicas", kind, name, namespace, target),
+				Status:  utils.SuccessStr,
+			}, nil, nil
+		}
+		previous = strconv.Itoa(int(current))
+	}
+
+	// a workload already scaled without ttl stays scaled
+	var expiresAt *string
+	if !scaled {
+		if parameters.TTL != "" {
+			v := ttl.ExpiresAt(parameters.TTL)
+			expiresAt = &v
+		}
+	} else {
+		v, ok := annotations[ttl.ScaleAnnotation]
+		if v, keep := ttl.Update(v, ok, parameters.TTL); keep {
+			expiresAt = &v
+		}
+	}
+
+	rollback, _ := json.Marshal(state{Kind: kind, Name: name, Namespace: namespace, Replicas: current, Scaled: scaled})
+
+	if err := scale(ctx, kind, name, namespace, scalePatch(annotations, &previous, expiresAt, target)); err != nil {
+		return utils.LogLine{
+			Objects: objects,
+			Error:   err.Error(),
+			Status:  utils.FailureStr,
+		}, nil, err
+	}
+
+	return utils.LogLine{
+		Objects: objects,
+		Output:  fmt.Sprintf("the %v '%v' in the namespace '%v' has been scaled from %v to %v replicas", kind, name, namespace, current, target),
+		Status:  utils.SuccessStr,
+	}, &models.Data{Rollback: rollback}, nil
+}
+
+func (a Actionner) Rollback(ctx context.Context, b []byte) (utils.LogLine, error) {
+	var previous state
+	if err := json.Unmarshal(b, &previous); err != nil {
+		return utils.LogLine{Status: utils.FailureStr, Error: err.Error()}, err
+	}
+
+	objects := map[string]string{
+		previous.Kind: previous.Name,
+		"namespace":   previous.Namespace,
+	}
+
+	annotations, err := getAnnotations(ctx, previous.Kind, previous.Name, previous.Namespace)
+	if err != nil {
+		return utils.LogLine{
+			Objects: objects,
+			Error:   err.Error(),
+			Status:  utils.FailureStr,
+		}, err
+	}
+
+	// the annotations are kept if the workload was already scaled before the action
+	var payload []byte
+	if previous.Scaled {
+		payload, _ = json.Marshal(map[string]any{"spec": map[string]any{"replicas": previous.Replicas}})
+	} else {
+		payload = scalePatch(annotations, nil, nil, previous.Replicas)
+	}
+	if err := scale(ctx, previous.Kind, previous.Name, previous.Namespace, payload); err != nil {
+		return utils.LogLine{
+			Objects: objects,
+			Error:   err.Error(),
+			Status:  utils.FailureStr,
+		}, err
+	}
+
+	return utils.LogLine{
+		Objects: objects,
+		Output:  fmt.Sprintf("the %v '%v' in the namespace '%v' has been restored to %v replicas", previous.Kind, previous.Name, previous.Namespace, previous.Replicas),
+		Status:  utils.SuccessStr,
+	}, nil
+}
+
+// Reconcile restores the previous number of replicas of the deployments and the statefulsets with an expired ttl
+func (a Actionner) Reconcile(ctx context.Context) ([]utils.LogLine, error) {
+	client := k8s.GetClient()
+	opts := metav1.ListOptions{LabelSelector: ttl.Selector()}
+
+	results := make([]utils.LogLine, 0)
+
+	deployments, err := client.Clientset.AppsV1().Deployments("").List(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	for _, i := range deployments.Items {
+		if log := restoreExpired(ctx, deploymentStr, i.ObjectMeta); log != nil {
+			results = append(results, *log)
+		}
+	}
+
+	statefulsets, err := client.Clientset.AppsV1().StatefulSets("").List(ctx, opts)
+	if err != nil {
+		return results, err
+	}
+	for _, i := range statefulsets.Items {
+		if log := restoreExpired(ctx, statefulsetStr, i.ObjectMeta); log != nil {
+			results = append(results, *log)
+		}
+	}
+
+	return results, nil
+}
+
+// restoreExpired restores the previous number of replicas of a workload if its scaling has expired
+func restoreExpired(ctx context.Context, kind string, meta metav1.ObjectMeta) *utils.LogLine {
+	v, ok := meta.Annotations[ttl.ScaleAnnotation]
+	if !ok || !ttl.IsExpired(v) {
+		return nil
+	}
+	log := utils.LogLine{
+		Objects: map[string]string{kind: meta.Name, "namespace": meta.Namespace},
+	}
+	replicas, err := strconv.ParseInt(meta.Annotations[PreviousReplicasAnnotation], 10, 32)
+	if err != nil {
+		log.Status = utils.FailureStr
+		log.Error = fmt.Sprintf("wrong value for the annotation '%v': %v", PreviousReplicasAnnotation, err.Error())
+		return &log
+	}
+	err = scale(ctx, kind, meta.Name, meta.Namespace, scalePatch(meta.Annotations, nil, nil, int32(replicas)))
+	if err != nil {
+		log.Status = utils.FailureStr
+		log.Error = err.Error()
+	} else {
+		log.Status = utils.SuccessStr
+		log.Output = fmt.Sprintf("the scaling of the %v '%v' in the namespace '%v' has expired, it has been restored to %v replicas", kind, meta.Name, meta.Namespace, replicas)
+	}
+	return &log
+}
+
+// scalePatch returns the merge patch setting the number of replicas and the annotations, nil values remove them
+func scalePatch(annotations map[string]string, previous, expiresAt *string, replicas int32) []byte {
+	current := make(map[string]string, len(annotations)+1)
+	for i, j := range annotations {
+		current[i] = j
+	}
+	if previous != nil {
+		current[PreviousReplicasAnnotation] = *previous
+	} else {
+		delete(current, PreviousReplicasAnnotation)
+	}
+	metadata := ttl.MetadataPatch(current, ttl.ScaleAnnotation, expiresAt)
+	metadata["annotations"].(map[string]*string)[PreviousReplicasAnnotation] = previous
+
+	payload, _ := json.Marshal(map[string]any{
+		"metadata": metadata,
+		"spec":     map[string]any{"replicas": replicas},
+	})
+	return payload
+}
+
+func getAnnotations(ctx context.Context, kind, name, namespace string) (map[string]string, error) {
+	client := k8s.GetClient()
+	if kind == statefulsetStr {
+		u, err := client.GetStatefulSet(ctx, name, namespace)
+		if err != nil {
+			return nil, err
+		}
+		return u.ObjectMeta.Annotations, nil
+	}
+	u, err := client.GetDeployment(ctx, name, namespace)
+	if err != nil {
+		return nil, err
+	}
+	return u.ObjectMeta.Annotations, nil
+}
+
+func scale(ctx context.Context, kind, name, namespace string, payload []byte) error {
+	client := k8s.GetClient()
+	var err error
+	if kind == statefulsetStr {
+		_, err = client.Clientset.AppsV1().StatefulSets(namespace).Patch(ctx, name, types.MergePatchType, payload, metav1.PatchOptions{})
+	} else {
+		_, err = client.Clientset.AppsV1().Deployments(namespace).Patch(ctx, name, types.MergePatchType, payload, metav1.PatchOptions{})
+	}
+	return err
+}
+
+func (a Actionner) CheckParameters(action *rules.Action) error {
+	var parameters Parameters
+	err := utils.DecodeParams(action.GetParameters(), &parameters)
+	if err != nil {
+		return err
+	}
+
+	if err := ttl.Check(parameters.TTL); err != nil {
+		return err
+	}
+
+	return utils.ValidateStruct(parameters)
+}
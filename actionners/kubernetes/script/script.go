@@ -2,6 +2,7 @@ package script
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"os"
@@ -102,7 +103,7 @@ func (a Actionner) Checks(event *events.Event, _ *rules.Action) error {
 	return k8sChecks.CheckPodExist(event)
 }
 
-func (a Actionner) Run(event *events.Event, action *rules.Action) (utils.LogLine, *models.Data, error) {
+func (a Actionner) Run(ctx context.Context, event *events.Event, action *rules.Action) (utils.LogLine, *models.Data, error) {
 	pod := event.GetPodName()
 	namespace := event.GetNamespaceName()
 
@@ -152,7 +153,7 @@ func (a Actionner) Run(event *events.Event, action *rules.Action) (utils.LogLine
 
 	client := k8s.GetClient()
 
-	p, _ := client.GetPod(pod, namespace)
+	p, _ := client.GetPod(ctx, pod, namespace)
 	containers := k8s.GetContainers(p)
 	if len(containers) == 0 {
 		err = fmt.Errorf("no container found")
@@ -171,7 +172,7 @@ func (a Actionner) Run(event *events.Event, action *rules.Action) (utils.LogLine
 	for i, j := range containers {
 		container = j
 		command := []string{"tee", "/tmp/talon-script.sh", ">", "/dev/null"}
-		_, err = client.Exec(namespace, pod, container, command, *script)
+		_, err = client.Exec(ctx, namespace, pod, container, command, *script)
 		if err != nil {
 			if i == len(containers)-1 {
 				return utils.LogLine{
@@ -186,7 +187,7 @@ func (a Actionner) Run(event *events.Event, action *rules.Action) (utils.LogLine
 
 	// run the script
 	command := []string{*shell, "/tmp/talon-script.sh"}
-	output, err = client.Exec(namespace, pod, container, command, "")
+	output, err = client.Exec(ctx, namespace, pod, container, command, "")
 	if err != nil {
 		return utils.LogLine{
 			Objects: objects,
@@ -1,6 +1,7 @@
 package tcpdump
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/google/uuid"
@@ -119,7 +120,7 @@ func (a Actionner) Checks(event *events.Event, _ *rules.Action) error {
 	return k8sChecks.CheckPodExist(event)
 }
 
-func (a Actionner) Run(event *events.Event, action *rules.Action) (utils.LogLine, *models.Data, error) {
+func (a Actionner) Run(ctx context.Context, event *events.Event, action *rules.Action) (utils.LogLine, *models.Data, error) {
 	podName := event.GetPodName()
 	namespace := event.GetNamespaceName()
 
@@ -148,7 +149,7 @@ func (a Actionner) Run(event *events.Event, action *rules.Action) (utils.LogLine
 
 	client := k8s.GetClient()
 
-	pod, _ := client.GetPod(podName, namespace)
+	pod, _ := client.GetPod(ctx, podName, namespace)
 	containers := k8s.GetContainers(pod)
 	if len(containers) == 0 {
 		err = fmt.Errorf("no container found")
@@ -161,7 +162,7 @@ func (a Actionner) Run(event *events.Event, action *rules.Action) (utils.LogLine
 
 	ephemeralContainerName := fmt.Sprintf("%v%v", baseName, uuid.NewString()[:5])
 
-	err = client.CreateEphemeralContainer(pod, containers[0], ephemeralContainerName, parameters.Image, defaultTTL)
+	err = client.CreateEphemeralContainer(ctx, pod, containers[0], ephemeralContainerName, parameters.Image, defaultTTL)
 	if err != nil {
 		return utils.LogLine{
 			Objects: objects,
@@ -172,7 +173,7 @@ func (a Actionner) Run(event *events.Event, action *rules.Action) (utils.LogLine
 
 	command := []string{"tee", "/tmp/talon-script.sh", ">", "/dev/null"}
 	script := fmt.Sprintf("timeout %vs tcpdump -n -i any -s %v -w /tmp/tcpdump.pcap || [ $? -eq 124 ] && echo OK || exit 1", parameters.Duration, parameters.Snaplen)
-	_, err = client.Exec(namespace, podName, ephemeralContainerName, command, script)
+	_, err = client.Exec(ctx, namespace, podName, ephemeralContainerName, command, script)
 	if err != nil {
 		return utils.LogLine{
 			Objects: objects,
@@ -182,7 +183,7 @@ func (a Actionner) Run(event *events.Event, action *rules.Action) (utils.LogLine
 	}
 
 	command = []string{"sh", "/tmp/talon-script.sh"}
-	_, err = client.Exec(namespace, podName, ephemeralContainerName, command, "")
+	_, err = client.Exec(ctx, namespace, podName, ephemeralContainerName, command, "")
 	if err != nil {
 		return utils.LogLine{
 			Objects: objects,
@@ -192,7 +193,7 @@ func (a Actionner) Run(event *events.Event, action *rules.Action) (utils.LogLine
 	}
 
 	command = []string{"cat", "/tmp/tcpdump.pcap"}
-	output, err := client.Exec(namespace, podName, ephemeralContainerName, command, "")
+	output, err := client.Exec(ctx, namespace, podName, ephemeralContainerName, command, "")
 	if err != nil {
 		return utils.LogLine{
 			Objects: objects,
@@ -103,7 +103,7 @@ func (a Actionner) Checks(event *events.Event, _ *rules.Action) error {
 	return k8sChecks.CheckPodExist(event)
 }
 
-func (a Actionner) Run(event *events.Event, action *rules.Action) (utils.LogLine, *models.Data, error) {
+func (a Actionner) Run(ctx context.Context, event *events.Event, action *rules.Action) (utils.LogLine, *models.Data, error) {
 	podName := event.GetPodName()
 	namespace := event.GetNamespaceName()
 
@@ -126,7 +126,7 @@ func (a Actionner) Run(event *events.Event, action *rules.Action) (utils.LogLine
 	*gracePeriodSeconds = int64(parameters.GracePeriodSeconds)
 
 	client := k8s.GetClient()
-	pod, err := client.GetPod(podName, namespace)
+	pod, err := client.GetPod(ctx, podName, namespace)
 	if err != nil {
 		return utils.LogLine{
 				Objects: objects,
@@ -175,7 +175,7 @@ func (a Actionner) Run(event *events.Event, action *rules.Action) (utils.LogLine
 			}, nil, nil
 		}
 		if parameters.MinHealthyReplicas != "" {
-			replicaSet, err2 := client.GetReplicaSet(replicaSetName, pod.Namespace)
+			replicaSet, err2 := client.GetReplicaSet(ctx, replicaSetName, pod.Namespace)
 			if err2 != nil {
 				return utils.LogLine{
 					Objects: objects,
@@ -228,7 +228,7 @@ func (a Actionner) Run(event *events.Event, action *rules.Action) (utils.LogLine
 		}
 	}
 
-	err = client.Clientset.CoreV1().Pods(namespace).Delete(context.Background(), podName, metav1.DeleteOptions{GracePeriodSeconds: gracePeriodSeconds})
+	err = client.Clientset.CoreV1().Pods(namespace).Delete(ctx, podName, metav1.DeleteOptions{GracePeriodSeconds: gracePeriodSeconds})
 	if err != nil {
 		return utils.LogLine{
 				Objects: objects,
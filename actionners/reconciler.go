@@ -0,0 +1,50 @@
+package actionners
+
+import (
+	"context"
+	"time"
+
+	"github.com/falcosecurity/falco-talon/internal/leaderelection"
+	"github.com/falcosecurity/falco-talon/utils"
+)
+
+// Reconciler is implemented by the actionners able to revert their changes once their ttl has expired
+type Reconciler interface {
+	Reconcile(ctx context.Context) ([]utils.LogLine, error)
+}
+
+const reconcileInterval = 30 * time.Second
+
+// StartReconciler periodically reverts the changes with an expired ttl, the state is stored
+// in the annotations of the objects, it survives the restarts and the changes of leader; only the
+// leader reconciles, to avoid conflicting updates between the replicas
+func StartReconciler() {
+	for {
+		time.Sleep(reconcileInterval)
+		if !leaderelection.IsLeader() {
+			continue
+		}
+		for _, i := range *ListActionners() {
+			reconciler, ok := i.(Reconciler)
+			if !ok {
+				continue
+			}
+			// each reconciliation must end before the next one
+			ctx, cancel := context.WithTimeout(context.Background(), reconcileInterval)
+			results, err := reconciler.Reconcile(ctx)
+			cancel()
+			for _, j := range results {
+				j.Message = "ttl"
+				j.Actionner = i.Information().FullName
+				if j.Status == utils.FailureStr {
+					utils.PrintLog("error", j)
+					continue
+				}
+				utils.PrintLog("info", j)
+			}
+			if err != nil {
+				utils.PrintLog("error", utils.LogLine{Error: err.Error(), Message: "ttl", Actionner: i.Information().FullName})
+			}
+		}
+	}
+}
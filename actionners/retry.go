@@ -0,0 +1,99 @@
+package actionners
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	errorsv1 "k8s.io/apimachinery/pkg/api/errors"
+
+	"github.com/falcosecurity/falco-talon/internal/otlp/metrics"
+	"github.com/falcosecurity/falco-talon/internal/otlp/traces"
+	"github.com/falcosecurity/falco-talon/internal/rules"
+	"github.com/falcosecurity/falco-talon/utils"
+)
+
+// withRetry calls run until it succeeds, the attempts are exhausted, the context is done or the error
+// is not retryable. Each attempt is traced in its own span and each failed attempt is logged with
+// the log line of the action or the output
+func withRetry(ctx context.Context, retry *rules.Retry, log utils.LogLine, run func(ctx context.Context) error) error {
+	if retry == nil {
+		return run(ctx)
+	}
+
+	tracer := traces.GetTracer()
+	attempts := retry.GetAttempts()
+	for n := 1; ; n++ {
+		actx, span := tracer.Start(ctx, "attempt", trace.WithAttributes(attribute.Int("attempt", n)))
+		err := run(actx)
+		if err == nil {
+			span.SetStatus(codes.Ok, "attempt successfully completed")
+			span.End()
+			return nil
+		}
+		kind := errorKind(err)
+		span.SetAttributes(attribute.String("error.kind", kind))
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		span.End()
+
+		if n >= attempts || ctx.Err() != nil || !retry.IsRetryable(kind) {
+			return err
+		}
+
+		delay := retry.GetDelay(n)
+		log.Status = utils.RetryStr
+		log.Error = err.Error()
+		log.Output = fmt.Sprintf("attempt %v/%v failed, next attempt in %v", n, attempts, delay)
+		utils.PrintLog("warning", log)
+		metrics.IncreaseCounter(log)
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(delay):
+		}
+	}
+}
+
+// errorKind returns the kind of the error, to know if it can be retried
+func errorKind(err error) string {
+	switch {
+	case errorsv1.IsNotFound(err), errorsv1.IsForbidden(err), errorsv1.IsUnauthorized(err),
+		errorsv1.IsInvalid(err), errorsv1.IsBadRequest(err), errorsv1.IsAlreadyExists(err),
+		errorsv1.IsMethodNotSupported(err), errors.Is(err, context.Canceled):
+		return rules.NonRetryableError
+	case errorsv1.IsTooManyRequests(err):
+		return rules.TooManyRequestsError
+	case errorsv1.IsConflict(err):
+		return rules.ConflictError
+	case errorsv1.IsServerTimeout(err), errorsv1.IsTimeout(err), errors.Is(err, context.DeadlineExceeded):
+		return rules.TimeoutError
+	case errorsv1.IsInternalError(err), errorsv1.IsServiceUnavailable(err), errorsv1.IsUnexpectedServerError(err):
+		return rules.ServerError
+	}
+
+	// the errors of the AWS SDK expose the HTTP status code of the response
+	var httpErr interface{ HTTPStatusCode() int }
+	if errors.As(err, &httpErr) {
+		switch code := httpErr.HTTPStatusCode(); {
+		case code == http.StatusTooManyRequests:
+			return rules.TooManyRequestsError
+		case code == http.StatusConflict:
+			return rules.ConflictError
+		case code == http.StatusRequestTimeout, code == http.StatusGatewayTimeout:
+			return rules.TimeoutError
+		case code >= http.StatusInternalServerError:
+			return rules.ServerError
+		case code >= http.StatusBadRequest:
+			return rules.NonRetryableError
+		}
+	}
+
+	return rules.OtherError
+}
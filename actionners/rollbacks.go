@@ -0,0 +1,145 @@
+package actionners
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/falcosecurity/falco-talon/internal/events"
+	"github.com/falcosecurity/falco-talon/internal/rollbacks"
+	"github.com/falcosecurity/falco-talon/internal/rules"
+	"github.com/falcosecurity/falco-talon/notifiers"
+	"github.com/falcosecurity/falco-talon/utils"
+)
+
+func recordChange(rule *rules.Rule, action *rules.Action, event *events.Event, state []byte) {
+	err := rollbacks.Add(&rollbacks.Change{
+		Time:      time.Now().UTC(),
+		TraceID:   event.TraceID,
+		Rule:      rule.GetName(),
+		Action:    action.GetName(),
+		Actionner: action.GetActionner(),
+		State:     state,
+	})
+	if err != nil {
+		utils.PrintLog("error", utils.LogLine{
+			Message:   "rollback",
+			Rule:      rule.GetName(),
+			Action:    action.GetName(),
+			Actionner: action.GetActionner(),
+			TraceID:   event.TraceID,
+			Error:     fmt.Sprintf("can't record the change: %v", err.Error()),
+		})
+	}
+}
+
+// StartRollback starts the rollback of the changes done by the actions for an event, its progress
+// is returned by rollbacks.GetStatus
+func StartRollback(traceID string) error {
+	changes, err := rollbacks.Take(traceID)
+	if err != nil {
+		return err
+	}
+
+	status := &rollbacks.Status{
+		StartedAt: time.Now().UTC(),
+		TraceID:   traceID,
+		Status:    utils.RunningStr,
+	}
+	if err := rollbacks.SetStatus(status); err != nil {
+		// the changes are kept for a next attempt
+		_ = rollbacks.Add(changes...)
+		return err
+	}
+
+	go func() {
+		results, err := rollback(changes)
+		endedAt := time.Now().UTC()
+		status.EndedAt = &endedAt
+		status.Results = results
+		status.Status = utils.SuccessStr
+		for _, i := range results {
+			if i.Status == utils.FailureStr {
+				status.Status = utils.FailureStr
+			}
+		}
+		if err != nil {
+			status.Status = utils.FailureStr
+			status.Error = err.Error()
+		}
+		if err := rollbacks.SetStatus(status); err != nil {
+			utils.PrintLog("error", utils.LogLine{Message: "rollback", TraceID: traceID, Error: err.Error()})
+		}
+	}()
+	return nil
+}
+
+// rollback reverts the changes, from the newest to the oldest, the changes which can't be reverted
+// are kept for a next attempt
+func rollback(changes []*rollbacks.Change) ([]utils.LogLine, error) {
+	results := make([]utils.LogLine, 0, len(changes))
+	failed := make([]*rollbacks.Change, 0)
+	for n := len(changes) - 1; n >= 0; n-- {
+		change := changes[n]
+		log := utils.LogLine{
+			Message:   "rollback",
+			Rule:      change.Rule,
+			Action:    change.Action,
+			Actionner: change.Actionner,
+			TraceID:   change.TraceID,
+		}
+
+		var result utils.LogLine
+		var err error
+		rule, action := findAction(change.Rule, change.Action)
+		actionner := ListActionners().FindActionner(change.Actionner)
+		rollbacker, ok := actionner.(Rollbacker)
+		if !ok {
+			err = fmt.Errorf("the actionner '%v' can't be rolled back", change.Actionner)
+		} else {
+			// the rollback is bounded by the timeout of the action, if the action still exists
+			ctx, cancel := context.WithCancel(context.Background())
+			if action != nil && action.GetTimeout() > 0 {
+				ctx, cancel = context.WithTimeout(context.Background(), action.GetTimeout())
+			}
+			result, err = rollbacker.Rollback(ctx, change.State)
+			cancel()
+		}
+
+		log.Objects = result.Objects
+		log.Output = result.Output
+		if err != nil {
+			log.Status = utils.FailureStr
+			log.Error = err.Error()
+			utils.PrintLog("error", log)
+			failed = append([]*rollbacks.Change{change}, failed...)
+		} else {
+			log.Status = utils.SuccessStr
+			utils.PrintLog("info", log)
+		}
+		results = append(results, log)
+
+		if action != nil {
+			go notifiers.Notify(context.Background(), rule, action, &events.Event{TraceID: change.TraceID}, log)
+		}
+	}
+
+	if err := rollbacks.Add(failed...); err != nil {
+		return results, err
+	}
+	return results, nil
+}
+
+func findAction(ruleName, actionName string) (*rules.Rule, *rules.Action) {
+	for _, i := range *rules.GetRules() {
+		if i.GetName() != ruleName {
+			continue
+		}
+		for _, j := range i.GetActions() {
+			if j.GetName() == actionName {
+				return i, j
+			}
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,222 @@
+package actionners
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+
+	talonContext "github.com/falcosecurity/falco-talon/internal/context"
+	"github.com/falcosecurity/falco-talon/internal/events"
+	"github.com/falcosecurity/falco-talon/internal/otlp/metrics"
+	"github.com/falcosecurity/falco-talon/internal/rules"
+	"github.com/falcosecurity/falco-talon/utils"
+)
+
+const (
+	pendingState int = iota
+	runningState
+	proceedState // the action is done and its dependents can run
+	stopState    // the action is done or skipped and its dependents must be skipped
+)
+
+type stepResult struct {
+	index   int
+	proceed bool
+}
+
+// schedule calls step for each action once all its dependencies have proceeded, the actions
+// depending on an action which doesn't proceed are skipped. If concurrent is false, the actions
+// are called one by one, in the order of the rule
+func schedule(dependencies [][]int, concurrent bool, step func(n int) bool) {
+	run(make([]int, len(dependencies)), dependencies, concurrent, step)
+}
+
+// scheduleFrom calls step for the action 'start' and then for its dependents, the other actions are
+// considered as already done
+func scheduleFrom(dependencies [][]int, start int, concurrent bool, step func(n int) bool) {
+	states := make([]int, len(dependencies))
+	for n := range states {
+		states[n] = proceedState
+	}
+	states[start] = pendingState
+	for changed := true; changed; {
+		changed = false
+		for n := range dependencies {
+			if states[n] == pendingState {
+				continue
+			}
+			for _, d := range dependencies[n] {
+				if states[d] == pendingState {
+					states[n] = pendingState
+					changed = true
+					break
+				}
+			}
+		}
+	}
+	run(states, dependencies, concurrent, step)
+}
+
+func run(states []int, dependencies [][]int, concurrent bool, step func(n int) bool) {
+	results := make(chan stepResult)
+	running := 0
+	for {
+		for changed := true; changed; {
+			changed = false
+			for n := range dependencies {
+				if states[n] != pendingState {
+					continue
+				}
+				ready := true
+				for _, d := range dependencies[n] {
+					if states[d] == stopState {
+						states[n] = stopState
+						changed = true
+						break
+					}
+					if states[d] != proceedState {
+						ready = false
+					}
+				}
+				if !ready || states[n] != pendingState {
+					continue
+				}
+				if !concurrent {
+					states[n] = proceedState
+					if !step(n) {
+						states[n] = stopState
+					}
+					changed = true
+					break
+				}
+				states[n] = runningState
+				running++
+				go func(n int) {
+					// a panic can't be recovered by the caller in another goroutine, the action is
+					// considered as failed and its dependents are skipped
+					proceed := false
+					defer func() {
+						if r := recover(); r != nil {
+							utils.PrintLog("error", utils.LogLine{Error: fmt.Sprintf("panic while running the action: %v", r), Message: "action"})
+						}
+						results <- stepResult{index: n, proceed: proceed}
+					}()
+					proceed = step(n)
+				}(n)
+			}
+		}
+		if running == 0 {
+			return
+		}
+		r := <-results
+		running--
+		states[r.index] = stopState
+		if r.proceed {
+			states[r.index] = proceedState
+		}
+	}
+}
+
+// runActions runs the actions of the rule for the event, following their dependencies and the
+// 'continue' and 'ignore_errors' settings, the errors of the failed actions are returned
+func runActions(mctx context.Context, rule *rules.Rule, event *events.Event) error {
+	actions := rule.GetActions()
+	dependencies, err := rule.GetDependencies()
+	if err != nil {
+		return nil // can't happen, the dependencies are validated when the rules are parsed
+	}
+
+	var mu sync.Mutex
+	errs := make([]error, 0)
+	schedule(dependencies, rule.IsParallel(), func(n int) bool {
+		proceed, err := runActionStep(mctx, rule, actions[n], event, true)
+		if err != nil {
+			mu.Lock()
+			errs = append(errs, fmt.Errorf("action '%v': %w", actions[n].GetName(), err))
+			mu.Unlock()
+		}
+		return proceed
+	})
+	return errors.Join(errs...)
+}
+
+// resumeActions runs an action of the rule and then the actions depending on it, the other actions are
+// considered as already done; it's used to resume the actions once a pending action has been approved and
+// to redrive a failed action. The action has already passed its rate limit and its cooldown, they're not
+// checked again
+func resumeActions(mctx context.Context, rule *rules.Rule, action *rules.Action, event *events.Event) {
+	actions := rule.GetActions()
+	dependencies, err := rule.GetDependencies()
+	if err != nil {
+		return // can't happen, the dependencies are validated when the rules are parsed
+	}
+	start := slices.IndexFunc(actions, func(i *rules.Action) bool { return i.GetName() == action.GetName() })
+	if start < 0 {
+		return
+	}
+
+	scheduleFrom(dependencies, start, rule.IsParallel(), func(n int) bool {
+		proceed, _ := runActionStep(mctx, rule, actions[n], event, n != start)
+		return proceed
+	})
+}
+
+// runActionStep runs an action and returns true if its dependents can run, and the error of the action
+// if it failed and its errors aren't ignored; the rate limit and the cooldown are checked if throttle is true
+func runActionStep(mctx context.Context, rule *rules.Rule, action *rules.Action, event *events.Event, throttle bool) (bool, error) {
+	if throttle && action.IsThrottled(rule, event) {
+		log := utils.LogLine{
+			Message:   "action",
+			Rule:      rule.GetName(),
+			Event:     event.Output,
+			Action:    action.GetName(),
+			Actionner: action.GetActionner(),
+			Status:    utils.ThrottledStr,
+			TraceID:   event.TraceID,
+		}
+		utils.PrintLog("info", log)
+		metrics.IncreaseCounter(log)
+		return continueAfter(action), nil
+	}
+
+	// each action gets its own copy of the context, the actions may run concurrently
+	e := new(events.Event)
+	*e = *event
+	e.Context = maps.Clone(event.Context)
+	rule.AddFalcoTalonContext(e, action)
+	if ListDefaultActionners().FindActionner(action.GetActionner()).Information().UseContext &&
+		len(action.GetAdditionalContexts()) != 0 {
+		for _, j := range action.GetAdditionalContexts() {
+			elements, err := talonContext.GetContext(mctx, j, e)
+			if err != nil {
+				log := utils.LogLine{
+					Message:   "context",
+					Context:   j,
+					Rule:      e.Rule,
+					Action:    action.GetName(),
+					Actionner: action.GetActionner(),
+					TraceID:   e.TraceID,
+					Error:     err.Error(),
+				}
+				utils.PrintLog("error", log)
+				if action.IgnoreErrors != trueStr {
+					break
+				}
+			} else {
+				e.AddContext(elements)
+			}
+		}
+	}
+	err := runAction(mctx, rule, action, e)
+	if errors.Is(err, errPending) {
+		// the dependents run once the action is approved
+		return false, nil
+	}
+	if err != nil && action.IgnoreErrors != trueStr {
+		return false, err
+	}
+	return continueAfter(action), nil
+}
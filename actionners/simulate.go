@@ -0,0 +1,69 @@
+package actionners
+
+import (
+	"github.com/falcosecurity/falco-talon/internal/events"
+	"github.com/falcosecurity/falco-talon/internal/rules"
+)
+
+// SimulatedMatch is a rule matching an event with the actions it would run
+type SimulatedMatch struct {
+	Rule    string            `json:"rule" yaml:"rule"`
+	Actions []SimulatedAction `json:"actions" yaml:"actions"`
+}
+
+// SimulatedAction is an action which would be run for an event
+type SimulatedAction struct {
+	Parameters       map[string]any `json:"parameters,omitempty" yaml:"parameters,omitempty"`
+	Name             string         `json:"action" yaml:"action"`
+	Actionner        string         `json:"actionner" yaml:"actionner"`
+	DependsOn        []string       `json:"depends_on,omitempty" yaml:"depends_on,omitempty"`
+	ApprovalRequired bool           `json:"approval_required,omitempty" yaml:"approval_required,omitempty"`
+	DryRun           bool           `json:"dry_run,omitempty" yaml:"dry_run,omitempty"`
+}
+
+// Simulate returns the rules matching the event and the actions they would run, following the
+// order, the dependencies and the 'continue' settings used by StartConsumer, the actionners are
+// never called and the actions are considered successful
+func Simulate(event *events.Event) []SimulatedMatch {
+	matches := make([]SimulatedMatch, 0)
+	for _, i := range *rules.GetRules() {
+		if !i.CompareRule(event) {
+			continue
+		}
+		m := SimulatedMatch{Rule: i.GetName(), Actions: make([]SimulatedAction, 0)}
+		dependencies, err := i.GetDependencies()
+		if err != nil {
+			continue
+		}
+		actions := i.GetActions()
+		schedule(dependencies, false, func(n int) bool {
+			a := actions[n]
+			m.Actions = append(m.Actions, SimulatedAction{
+				Name:             a.GetName(),
+				Actionner:        a.GetActionner(),
+				Parameters:       a.Render(event).GetParameters(),
+				DependsOn:        a.DependsOn,
+				ApprovalRequired: a.RequireApproval(),
+				DryRun:           i.DryRun == trueStr,
+			})
+			return continueAfter(a)
+		})
+		matches = append(matches, m)
+		if i.Continue == falseStr {
+			break
+		}
+	}
+	return matches
+}
+
+// continueAfter returns true if the next actions of the rule have to be run after this one
+func continueAfter(action *rules.Action) bool {
+	if action.Continue == falseStr {
+		return false
+	}
+	if action.Continue == trueStr {
+		return true
+	}
+	a := ListDefaultActionners().FindActionner(action.GetActionner())
+	return a != nil && a.Information().Continue
+}
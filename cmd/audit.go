@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/falcosecurity/falco-talon/configuration"
+	"github.com/falcosecurity/falco-talon/internal/audit"
+	"github.com/falcosecurity/falco-talon/utils"
+)
+
+var auditCmd = &cobra.Command{
+	Use:   "audit",
+	Short: "Query the audit log",
+	Long: `Query the audit log of Falco Talon. The records of the matches, actions, outputs and notifications
+are printed in the stdout as JSON lines.`,
+	Run: func(cmd *cobra.Command, _ []string) {
+		configFile, _ := cmd.Flags().GetString("config")
+		config := configuration.CreateConfiguration(configFile)
+		utils.SetLogFormat(config.LogFormat)
+
+		file, _ := cmd.Flags().GetString("file")
+		if file == "" {
+			file = config.Audit.File
+		}
+
+		var filter audit.Filter
+		var err error
+		filter.TraceID, _ = cmd.Flags().GetString("trace-id")
+		filter.Rule, _ = cmd.Flags().GetString("rule")
+		filter.Namespace, _ = cmd.Flags().GetString("namespace")
+		since, _ := cmd.Flags().GetString("since")
+		if filter.Since, err = utils.ParseTime(since); err != nil {
+			utils.PrintLog("fatal", utils.LogLine{Error: err.Error(), Message: "audit"})
+		}
+		until, _ := cmd.Flags().GetString("until")
+		if filter.Until, err = utils.ParseTime(until); err != nil {
+			utils.PrintLog("fatal", utils.LogLine{Error: err.Error(), Message: "audit"})
+		}
+
+		records, err := audit.Query(audit.Files(file, config.Audit.MaxFiles), filter)
+		if err != nil {
+			utils.PrintLog("fatal", utils.LogLine{Error: err.Error(), Message: "audit"})
+		}
+		for _, i := range records {
+			b, _ := json.Marshal(i)
+			fmt.Println(string(b))
+		}
+	},
+}
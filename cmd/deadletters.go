@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/falcosecurity/falco-talon/utils"
+)
+
+var deadlettersCmd = &cobra.Command{
+	Use:   "deadletters",
+	Short: "Manage the actions which failed permanently",
+	Long: `List, inspect and redrive the actions which failed permanently.
+The requests are sent to the running Falco Talon.`,
+	Run: nil,
+}
+
+var deadlettersListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the failed actions",
+	Long:  "List the failed actions, from the oldest to the newest.",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, _ []string) {
+		body := callDeadLetters(cmd, http.MethodGet, "")
+		var entries []json.RawMessage
+		if err := json.Unmarshal(body, &entries); err != nil {
+			utils.PrintLog("fatal", utils.LogLine{Error: err.Error(), Message: "deadletter"})
+		}
+		for _, i := range entries {
+			fmt.Println(string(i))
+		}
+	},
+}
+
+var deadlettersGetCmd = &cobra.Command{
+	Use:   "get <id>",
+	Short: "Print a failed action",
+	Long:  "Print a failed action, with its event and its error.",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		body := callDeadLetters(cmd, http.MethodGet, args[0])
+		var out bytes.Buffer
+		if err := json.Indent(&out, body, "", "  "); err != nil {
+			utils.PrintLog("fatal", utils.LogLine{Error: err.Error(), Message: "deadletter"})
+		}
+		fmt.Println(out.String())
+	},
+}
+
+var deadlettersRedriveCmd = &cobra.Command{
+	Use:   "redrive <id>",
+	Short: "Run again a failed action",
+	Long:  "Run again a failed action, once the cause of its failure is fixed. If it fails again, a new dead letter is recorded.",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		callDeadLetters(cmd, http.MethodPost, args[0]+"/redrive")
+		utils.PrintLog("info", utils.LogLine{Result: args[0], Output: "the action is redriven", Message: "deadletter"})
+	},
+}
+
+func callDeadLetters(cmd *cobra.Command, method, path string) []byte {
+	address, _ := cmd.Flags().GetString("address")
+	url := strings.TrimSuffix(address, "/") + "/api/v1/deadletters"
+	if path != "" {
+		url += "/" + path
+	}
+
+	body, code, err := callAPI(cmd, method, url)
+	if err != nil {
+		utils.PrintLog("fatal", utils.LogLine{Error: err.Error(), Message: "deadletter"})
+	}
+	if code != http.StatusOK {
+		utils.PrintLog("fatal", utils.LogLine{Error: strings.TrimSpace(string(body)), Message: "deadletter"})
+	}
+	return body
+}
@@ -0,0 +1,153 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/falcosecurity/falco-talon/actionners"
+	"github.com/falcosecurity/falco-talon/configuration"
+	"github.com/falcosecurity/falco-talon/internal/events"
+	ruleengine "github.com/falcosecurity/falco-talon/internal/rules"
+	"github.com/falcosecurity/falco-talon/utils"
+)
+
+const (
+	tableStr string = "table"
+	jsonStr  string = "json"
+)
+
+type replayResult struct {
+	Error    string                      `json:"error,omitempty"`
+	Event    string                      `json:"event,omitempty"`
+	Priority string                      `json:"priority,omitempty"`
+	Matches  []actionners.SimulatedMatch `json:"matches"`
+	Line     int                         `json:"line"`
+}
+
+var replayCmd = &cobra.Command{
+	Use:   "replay",
+	Short: "Replay Falco events against the rules",
+	Long: `Replay Falco events, read as JSON lines, against the rules and report the rules and actions which would be triggered.
+No actionner is called.`,
+	Run: func(cmd *cobra.Command, _ []string) {
+		configFile, _ := cmd.Flags().GetString("config")
+		config := configuration.CreateConfiguration(configFile)
+		utils.SetLogFormat(config.LogFormat)
+		rulesFiles, _ := cmd.Flags().GetStringArray("rules")
+		if len(rulesFiles) != 0 {
+			config.RulesFiles = rulesFiles
+		}
+		format, _ := cmd.Flags().GetString("output")
+		if format != tableStr && format != jsonStr {
+			utils.PrintLog("fatal", utils.LogLine{Error: fmt.Sprintf("unknown output format '%v'", format), Message: "replay"})
+		}
+		rules := ruleengine.ParseRules(config.RulesFiles)
+		if rules == nil {
+			utils.PrintLog("fatal", utils.LogLine{Error: "invalid rules", Message: "rules"})
+		}
+
+		eventsFile, _ := cmd.Flags().GetString("events")
+		results, err := replayEvents(eventsFile)
+		if err != nil {
+			utils.PrintLog("fatal", utils.LogLine{Error: err.Error(), Message: "replay"})
+		}
+
+		if format == jsonStr {
+			b, _ := json.MarshalIndent(results, "", "  ")
+			fmt.Println(string(b))
+		} else {
+			printReplayTable(results)
+		}
+
+		for _, i := range results {
+			if i.Error != "" {
+				os.Exit(1)
+			}
+		}
+	},
+}
+
+func replayEvents(file string) ([]replayResult, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	results := make([]replayResult, 0)
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	var n int
+	for scanner.Scan() {
+		n++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		r := replayResult{Line: n, Matches: make([]actionners.SimulatedMatch, 0)}
+		event, err := events.DecodeEvent(strings.NewReader(line))
+		if err != nil {
+			r.Error = err.Error()
+			results = append(results, r)
+			continue
+		}
+		r.Event = event.Rule
+		r.Priority = event.Priority
+		r.Matches = actionners.Simulate(event)
+		results = append(results, r)
+	}
+	return results, scanner.Err()
+}
+
+func printReplayTable(results []replayResult) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "LINE\tEVENT\tRULE\tACTION\tACTIONNER\tPARAMETERS")
+	for _, i := range results {
+		if i.Error != "" {
+			fmt.Fprintf(w, "%v\t%v\t-\t-\t-\terror: %v\n", i.Line, i.Event, i.Error)
+			continue
+		}
+		if len(i.Matches) == 0 {
+			fmt.Fprintf(w, "%v\t%v\t-\t-\t-\t-\n", i.Line, i.Event)
+			continue
+		}
+		for _, j := range i.Matches {
+			if len(j.Actions) == 0 {
+				fmt.Fprintf(w, "%v\t%v\t%v\t-\t-\t-\n", i.Line, i.Event, j.Rule)
+				continue
+			}
+			for _, k := range j.Actions {
+				fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\t%v\n", i.Line, i.Event, j.Rule, k.Name, k.Actionner, formatParameters(k.Parameters))
+			}
+		}
+	}
+	w.Flush()
+}
+
+// formatParameters returns the parameters as a single line, sorted by key
+func formatParameters(parameters map[string]any) string {
+	if len(parameters) == 0 {
+		return "-"
+	}
+	keys := make([]string, 0, len(parameters))
+	for i := range parameters {
+		keys = append(keys, i)
+	}
+	sort.Strings(keys)
+	s := make([]string, 0, len(keys))
+	for _, i := range keys {
+		b, err := json.Marshal(parameters[i])
+		if err != nil {
+			b = []byte(fmt.Sprintf("%v", parameters[i]))
+		}
+		s = append(s, fmt.Sprintf("%v=%s", i, b))
+	}
+	return strings.Join(s, " ")
+}
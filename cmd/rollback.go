@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/falcosecurity/falco-talon/internal/rollbacks"
+	"github.com/falcosecurity/falco-talon/utils"
+)
+
+const pollInterval = 2 * time.Second
+
+var rollbackCmd = &cobra.Command{
+	Use:   "rollback",
+	Short: "Rollback the actions of an event",
+	Long: `Rollback the changes done by the actions for an event, identified by its trace id.
+The request is sent to the running Falco Talon, which reverts the changes it recorded,
+the command waits for the end of the rollback.`,
+	Run: func(cmd *cobra.Command, _ []string) {
+		traceID, _ := cmd.Flags().GetString("trace-id")
+		address, _ := cmd.Flags().GetString("address")
+		wait, _ := cmd.Flags().GetDuration("wait")
+		url := strings.TrimSuffix(address, "/") + "/api/v1/rollbacks/" + traceID
+
+		body, code, err := callAPI(cmd, http.MethodPost, url)
+		if err != nil {
+			utils.PrintLog("fatal", utils.LogLine{Error: err.Error(), Message: "rollback", TraceID: traceID})
+		}
+		if code != http.StatusAccepted {
+			utils.PrintLog("fatal", utils.LogLine{Error: strings.TrimSpace(string(body)), Message: "rollback", TraceID: traceID})
+		}
+
+		var status rollbacks.Status
+		for deadline := time.Now().Add(wait); ; {
+			time.Sleep(pollInterval)
+			body, code, err = callAPI(cmd, http.MethodGet, url)
+			if err != nil {
+				utils.PrintLog("fatal", utils.LogLine{Error: err.Error(), Message: "rollback", TraceID: traceID})
+			}
+			if code != http.StatusOK {
+				utils.PrintLog("fatal", utils.LogLine{Error: strings.TrimSpace(string(body)), Message: "rollback", TraceID: traceID})
+			}
+			if err := json.Unmarshal(body, &status); err != nil {
+				utils.PrintLog("fatal", utils.LogLine{Error: err.Error(), Message: "rollback", TraceID: traceID})
+			}
+			if status.Status != utils.RunningStr {
+				break
+			}
+			if time.Now().After(deadline) {
+				utils.PrintLog("fatal", utils.LogLine{Error: fmt.Sprintf("the rollback is still running after %v", wait), Message: "rollback", TraceID: traceID})
+			}
+		}
+
+		for _, i := range status.Results {
+			if i.Status == utils.FailureStr {
+				utils.PrintLog("error", i)
+				continue
+			}
+			utils.PrintLog("info", i)
+		}
+		if status.Error != "" {
+			utils.PrintLog("error", utils.LogLine{Error: status.Error, Message: "rollback", TraceID: traceID})
+		}
+		fmt.Printf("%v change(s) processed\n", len(status.Results))
+	},
+}
+
+// callAPI sends a request to the API of Falco Talon, with the token of the 'token' flag or the API_TOKEN env var
+func callAPI(cmd *cobra.Command, method, url string) ([]byte, int, error) {
+	req, err := http.NewRequest(method, url, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	token, _ := cmd.Flags().GetString("token")
+	if token == "" {
+		token = os.Getenv("API_TOKEN")
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	client := http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, 0, err
+	}
+	return body, resp.StatusCode, nil
+}
@@ -1,6 +1,8 @@
 package cmd
 
 import (
+	"time"
+
 	"github.com/spf13/cobra"
 
 	"github.com/falcosecurity/falco-talon/utils"
@@ -31,8 +33,16 @@ func init() {
 	RootCmd.AddCommand(actionnersCmd)
 	RootCmd.AddCommand(outputsCmd)
 	RootCmd.AddCommand(notifiersCmd)
+	RootCmd.AddCommand(auditCmd)
+	RootCmd.AddCommand(rollbackCmd)
+	RootCmd.AddCommand(replayCmd)
+	RootCmd.AddCommand(deadlettersCmd)
 	rulesCmd.AddCommand(rulesChecksCmd)
 	rulesCmd.AddCommand(rulesPrintCmd)
+	rulesCmd.AddCommand(rulesTestCmd)
+	deadlettersCmd.AddCommand(deadlettersListCmd)
+	deadlettersCmd.AddCommand(deadlettersGetCmd)
+	deadlettersCmd.AddCommand(deadlettersRedriveCmd)
 	actionnersCmd.AddCommand(actionnersListCmd)
 	outputsCmd.AddCommand(outputsListCmd)
 	notifiersCmd.AddCommand(notifiersListCmd)
@@ -42,4 +52,25 @@ func init() {
 	actionnersCmd.PersistentFlags().StringP("config", "c", "", "Falco Talon Config File")
 	outputsCmd.PersistentFlags().StringP("config", "c", "", "Falco Talon Config File")
 	notifiersCmd.PersistentFlags().StringP("config", "c", "", "Falco Talon Config File")
+	auditCmd.Flags().StringP("config", "c", "/etc/falco-talon/config.yaml", "Falco Talon Config File")
+	auditCmd.Flags().StringP("file", "f", "", "Audit Log File (default is the one of the config)")
+	auditCmd.Flags().String("trace-id", "", "Filter on the Trace ID")
+	auditCmd.Flags().String("rule", "", "Filter on the Rule")
+	auditCmd.Flags().String("namespace", "", "Filter on the Namespace")
+	auditCmd.Flags().String("since", "", "Filter the records after a date (RFC3339) or a duration (eg: 1h)")
+	auditCmd.Flags().String("until", "", "Filter the records before a date (RFC3339) or a duration (eg: 1h)")
+	rollbackCmd.Flags().String("trace-id", "", "Trace ID of the event to rollback"+requiredStr)
+	rollbackCmd.Flags().StringP("address", "a", "http://localhost:2803", "Address of Falco Talon")
+	rollbackCmd.Flags().String("token", "", "API Token of Falco Talon (default is the env var API_TOKEN)")
+	rollbackCmd.Flags().Duration("wait", 5*time.Minute, "Maximum duration to wait for the end of the rollback")
+	_ = rollbackCmd.MarkFlagRequired("trace-id")
+	replayCmd.Flags().StringP("config", "c", "", "Falco Talon Config File")
+	replayCmd.Flags().StringP("events", "e", "", "File of Falco events, in JSON lines"+requiredStr)
+	replayCmd.Flags().StringP("output", "o", "table", "Format of the report: table or json")
+	_ = replayCmd.MarkFlagRequired("events")
+	rulesTestCmd.Flags().StringArrayP("tests", "t", []string{}, "Falco Talon Rules Tests File"+requiredStr)
+	rulesTestCmd.Flags().StringP("output", "o", "text", "Format of the report: text, json or junit")
+	_ = rulesTestCmd.MarkFlagRequired("tests")
+	deadlettersCmd.PersistentFlags().StringP("address", "a", "http://localhost:2803", "Address of Falco Talon")
+	deadlettersCmd.PersistentFlags().String("token", "", "API Token of Falco Talon (default is the env var API_TOKEN)")
 }
@@ -107,25 +107,37 @@ var rulesPrintCmd = &cobra.Command{
 			utils.PrintLog("fatal", utils.LogLine{Error: "invalid rules", Message: "rules"})
 		}
 		type yamlFile struct {
-			Name        string   `yaml:"rule"`
-			Description string   `yaml:"description,omitempty"`
-			Continue    string   `yaml:"continue,omitempty"`
-			DryRun      string   `yaml:"dry_run,omitempty"`
-			Notifiers   []string `yaml:"notifiers,omitempty"`
-			Actions     []struct {
+			Name          string                    `yaml:"rule"`
+			Description   string                    `yaml:"description,omitempty"`
+			Continue      string                    `yaml:"continue,omitempty"`
+			DryRun        string                    `yaml:"dry_run,omitempty"`
+			Parallel      string                    `yaml:"parallel,omitempty"`
+			Notifiers     []string                  `yaml:"notifiers,omitempty"`
+			RateLimit     *ruleengine.RateLimit     `yaml:"rate_limit,omitempty"`
+			Cooldown      *ruleengine.Cooldown      `yaml:"cooldown,omitempty"`
+			Deduplication *ruleengine.Deduplication `yaml:"deduplication,omitempty"`
+			Actions       []struct {
 				Parameters map[string]any `yaml:"parameters,omitempty"`
 				Output     struct {
-					Parameters map[string]any `yaml:"parameters"`
-					Target     string         `yaml:"target"`
+					Parameters map[string]any    `yaml:"parameters"`
+					Retry      *ruleengine.Retry `yaml:"retry,omitempty"`
+					Target     string            `yaml:"target"`
 				} `yaml:"output,omitempty"`
-				Name               string   `yaml:"action"`
-				Description        string   `yaml:"description,omitempty"`
-				Actionner          string   `yaml:"actionner"`
-				Continue           string   `yaml:"continue,omitempty"`
-				IgnoreErrors       string   `yaml:"ignore_errors,omitempty"`
-				AdditionalContexts []string `yaml:"additional_contexts,omitempty"`
+				Name               string                `yaml:"action"`
+				Description        string                `yaml:"description,omitempty"`
+				Actionner          string                `yaml:"actionner"`
+				Continue           string                `yaml:"continue,omitempty"`
+				IgnoreErrors       string                `yaml:"ignore_errors,omitempty"`
+				Approval           string                `yaml:"approval,omitempty"`
+				Timeout            string                `yaml:"timeout,omitempty"`
+				AdditionalContexts []string              `yaml:"additional_contexts,omitempty"`
+				DependsOn          []string              `yaml:"depends_on,omitempty"`
+				RateLimit          *ruleengine.RateLimit `yaml:"rate_limit,omitempty"`
+				Cooldown           *ruleengine.Cooldown  `yaml:"cooldown,omitempty"`
+				Retry              *ruleengine.Retry     `yaml:"retry,omitempty"`
 			} `yaml:"actions"`
 			Match struct {
+				Condition    string   `yaml:"condition,omitempty"`
 				OutputFields []string `yaml:"output_fields,omitempty"`
 				Priority     string   `yaml:"priority,omitempty"`
 				Source       string   `yaml:"source,omitempty"`
@@ -0,0 +1,254 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+
+	"github.com/spf13/cobra"
+	yaml "gopkg.in/yaml.v3"
+
+	"github.com/falcosecurity/falco-talon/actionners"
+	"github.com/falcosecurity/falco-talon/configuration"
+	"github.com/falcosecurity/falco-talon/internal/events"
+	ruleengine "github.com/falcosecurity/falco-talon/internal/rules"
+	"github.com/falcosecurity/falco-talon/utils"
+)
+
+const (
+	textStr  string = "text"
+	junitStr string = "junit"
+)
+
+// ruleTest is a sample Falco event with the expected behavior of the rules
+type ruleTest struct {
+	Event  map[string]any `yaml:"event"`
+	Name   string         `yaml:"name"`
+	Expect struct {
+		Rules   []string `yaml:"rules"`
+		Actions []struct {
+			Parameters map[string]any `yaml:"parameters"`
+			Rule       string         `yaml:"rule"`
+			Action     string         `yaml:"action"`
+			Actionner  string         `yaml:"actionner"`
+		} `yaml:"actions"`
+	} `yaml:"expect"`
+}
+
+type ruleTestResult struct {
+	File     string   `json:"file"`
+	Name     string   `json:"name"`
+	Failures []string `json:"failures,omitempty"`
+	Passed   bool     `json:"passed"`
+}
+
+var rulesTestCmd = &cobra.Command{
+	Use:   "test",
+	Short: "Test Falco Talon Rules with sample events",
+	Long: `Test Falco Talon Rules with files of sample Falco events and their expected matched rules, actions and parameters.
+No actionner is called.`,
+	Run: func(cmd *cobra.Command, _ []string) {
+		configFile, _ := cmd.Flags().GetString("config")
+		config := configuration.CreateConfiguration(configFile)
+		utils.SetLogFormat(config.LogFormat)
+		rulesFiles, _ := cmd.Flags().GetStringArray("rules")
+		if len(rulesFiles) != 0 {
+			config.RulesFiles = rulesFiles
+		}
+		format, _ := cmd.Flags().GetString("output")
+		if format != textStr && format != jsonStr && format != junitStr {
+			utils.PrintLog("fatal", utils.LogLine{Error: fmt.Sprintf("unknown output format '%v'", format), Message: "rules"})
+		}
+		rules := ruleengine.ParseRules(config.RulesFiles)
+		if rules == nil {
+			utils.PrintLog("fatal", utils.LogLine{Error: "invalid rules", Message: "rules"})
+		}
+
+		testsFiles, _ := cmd.Flags().GetStringArray("tests")
+		results := make([]ruleTestResult, 0)
+		for _, i := range testsFiles {
+			r, err := runRuleTests(i)
+			if err != nil {
+				utils.PrintLog("fatal", utils.LogLine{Error: err.Error(), Message: "rules"})
+			}
+			results = append(results, r...)
+		}
+
+		switch format {
+		case jsonStr:
+			b, _ := json.MarshalIndent(results, "", "  ")
+			fmt.Println(string(b))
+		case junitStr:
+			fmt.Println(toJUnit(results))
+		default:
+			for _, i := range results {
+				if i.Passed {
+					fmt.Printf("PASS  %v: %v\n", i.File, i.Name)
+					continue
+				}
+				fmt.Printf("FAIL  %v: %v\n", i.File, i.Name)
+				for _, j := range i.Failures {
+					fmt.Printf("        %v\n", j)
+				}
+			}
+		}
+
+		for _, i := range results {
+			if !i.Passed {
+				os.Exit(1)
+			}
+		}
+	},
+}
+
+func runRuleTests(file string) ([]ruleTestResult, error) {
+	f, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var tests []ruleTest
+	if err := yaml.Unmarshal(f, &tests); err != nil {
+		return nil, fmt.Errorf("wrong syntax for the tests file '%v': %v", file, err.Error())
+	}
+
+	results := make([]ruleTestResult, 0, len(tests))
+	for n, i := range tests {
+		r := ruleTestResult{File: file, Name: i.Name}
+		if r.Name == "" {
+			r.Name = fmt.Sprintf("test #%v", n+1)
+		}
+		r.Failures = i.run()
+		r.Passed = len(r.Failures) == 0
+		results = append(results, r)
+	}
+	return results, nil
+}
+
+// run returns the differences between the expected and the actual behavior of the rules for the event
+func (test *ruleTest) run() []string {
+	b, err := json.Marshal(test.Event)
+	if err != nil {
+		return []string{fmt.Sprintf("invalid event: %v", err.Error())}
+	}
+	event, err := events.DecodeEvent(bytes.NewReader(b))
+	if err != nil {
+		return []string{fmt.Sprintf("invalid event: %v", err.Error())}
+	}
+
+	matches := actionners.Simulate(event)
+	failures := make([]string, 0)
+
+	if test.Expect.Rules != nil {
+		rules := make([]string, 0, len(matches))
+		for _, i := range matches {
+			rules = append(rules, i.Rule)
+		}
+		if !reflect.DeepEqual(rules, test.Expect.Rules) {
+			failures = append(failures, fmt.Sprintf("expected rules [%v], got [%v]", strings.Join(test.Expect.Rules, ", "), strings.Join(rules, ", ")))
+		}
+	}
+
+	if test.Expect.Actions != nil {
+		actions := make([]actionners.SimulatedAction, 0)
+		actionsRules := make([]string, 0)
+		for _, i := range matches {
+			for _, j := range i.Actions {
+				actions = append(actions, j)
+				actionsRules = append(actionsRules, i.Rule)
+			}
+		}
+		if len(actions) != len(test.Expect.Actions) {
+			names := make([]string, 0, len(actions))
+			for _, i := range actions {
+				names = append(names, i.Name)
+			}
+			failures = append(failures, fmt.Sprintf("expected %v action(s), got %v [%v]", len(test.Expect.Actions), len(actions), strings.Join(names, ", ")))
+			return failures
+		}
+		for n, i := range test.Expect.Actions {
+			a := actions[n]
+			if i.Rule != "" && i.Rule != actionsRules[n] {
+				failures = append(failures, fmt.Sprintf("action #%v: expected rule '%v', got '%v'", n+1, i.Rule, actionsRules[n]))
+			}
+			if i.Action != "" && i.Action != a.Name {
+				failures = append(failures, fmt.Sprintf("action #%v: expected action '%v', got '%v'", n+1, i.Action, a.Name))
+			}
+			if i.Actionner != "" && i.Actionner != a.Actionner {
+				failures = append(failures, fmt.Sprintf("action #%v: expected actionner '%v', got '%v'", n+1, i.Actionner, a.Actionner))
+			}
+			for k, v := range i.Parameters {
+				if !equalParameters(v, a.Parameters[k]) {
+					e, _ := json.Marshal(v)
+					g, _ := json.Marshal(a.Parameters[k])
+					failures = append(failures, fmt.Sprintf("action #%v: expected parameter '%v' to be %s, got %s", n+1, k, e, g))
+				}
+			}
+		}
+	}
+
+	return failures
+}
+
+// equalParameters compares the values through their JSON representations, to ignore the differences of types
+func equalParameters(expected, actual any) bool {
+	var e, a any
+	be, err := json.Marshal(expected)
+	if err != nil {
+		return false
+	}
+	ba, err := json.Marshal(actual)
+	if err != nil {
+		return false
+	}
+	_ = json.Unmarshal(be, &e)
+	_ = json.Unmarshal(ba, &a)
+	return reflect.DeepEqual(e, a)
+}
+
+func toJUnit(results []ruleTestResult) string {
+	type failure struct {
+		Message string `xml:"message,attr"`
+		Text    string `xml:",chardata"`
+	}
+	type testcase struct {
+		Failure   *failure `xml:"failure,omitempty"`
+		Name      string   `xml:"name,attr"`
+		ClassName string   `xml:"classname,attr"`
+	}
+	type testsuite struct {
+		XMLName   xml.Name   `xml:"testsuite"`
+		Name      string     `xml:"name,attr"`
+		TestCases []testcase `xml:"testcase"`
+		Tests     int        `xml:"tests,attr"`
+		Failures  int        `xml:"failures,attr"`
+	}
+	type testsuites struct {
+		XMLName    xml.Name    `xml:"testsuites"`
+		TestSuites []testsuite `xml:"testsuite"`
+	}
+
+	var suites testsuites
+	index := map[string]int{}
+	for _, i := range results {
+		n, ok := index[i.File]
+		if !ok {
+			suites.TestSuites = append(suites.TestSuites, testsuite{Name: i.File})
+			n = len(suites.TestSuites) - 1
+			index[i.File] = n
+		}
+		c := testcase{Name: i.Name, ClassName: i.File}
+		if !i.Passed {
+			c.Failure = &failure{Message: i.Failures[0], Text: strings.Join(i.Failures, "\n")}
+			suites.TestSuites[n].Failures++
+		}
+		suites.TestSuites[n].Tests++
+		suites.TestSuites[n].TestCases = append(suites.TestSuites[n].TestCases, c)
+	}
+
+	b, _ := xml.MarshalIndent(suites, "", "  ")
+	return xml.Header + string(b)
+}
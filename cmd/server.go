@@ -16,7 +16,9 @@ import (
 
 	"github.com/falcosecurity/falco-talon/actionners"
 	"github.com/falcosecurity/falco-talon/configuration"
-	k8s "github.com/falcosecurity/falco-talon/internal/kubernetes/client"
+	"github.com/falcosecurity/falco-talon/internal/audit"
+	"github.com/falcosecurity/falco-talon/internal/history"
+	"github.com/falcosecurity/falco-talon/internal/leaderelection"
 	"github.com/falcosecurity/falco-talon/internal/nats"
 	ruleengine "github.com/falcosecurity/falco-talon/internal/rules"
 	"github.com/falcosecurity/falco-talon/notifiers"
@@ -102,6 +104,14 @@ var serverCmd = &cobra.Command{
 		// init notifiers
 		notifiers.Init()
 
+		// init the audit log
+		if err := audit.Init(); err != nil {
+			utils.PrintLog("fatal", utils.LogLine{Error: err.Error(), Message: "audit"})
+		}
+
+		// init the history of the events for the API
+		history.Init()
+
 		if rules != nil {
 			utils.PrintLog("info", utils.LogLine{Result: fmt.Sprintf("%v rule(s) has/have been successfully loaded", len(*rules)), Message: "init"})
 		}
@@ -204,36 +214,47 @@ var serverCmd = &cobra.Command{
 				}
 			}()
 		}
-		// start the local NATS
-		ns, err := nats.StartServer(config.Deduplication.TimeWindowSeconds)
-		if err != nil {
-			utils.PrintLog("fatal", utils.LogLine{Error: err.Error(), Message: "nats"})
+		if config.NATS.URL != "" {
+			// connect to the external NATS, the replicas share its stream
+			if err := nats.Connect(config.Deduplication.TimeWindowSeconds); err != nil {
+				utils.PrintLog("fatal", utils.LogLine{Error: err.Error(), Message: "nats"})
+			}
+			utils.PrintLog("info", utils.LogLine{Result: fmt.Sprintf("connected to '%v'", config.NATS.URL), Message: "nats"})
+		} else {
+			// start the local NATS
+			ns, err := nats.StartServer(config.Deduplication.TimeWindowSeconds)
+			if err != nil {
+				utils.PrintLog("fatal", utils.LogLine{Error: err.Error(), Message: "nats"})
+			}
+			defer ns.Shutdown()
 		}
-		defer ns.Shutdown()
 
-		// starts a goroutine to get the holder of the lease
+		// starts a goroutine to follow the leader election, the leader runs the background tasks and, with
+		// the embedded NATS, receives the events of the other replicas
 		if config.Deduplication.LeaderElection {
-			go func() {
-				err2 := k8s.Init()
-				if err2 != nil {
-					utils.PrintLog("fatal", utils.LogLine{Error: err2.Error(), Message: "lease"})
-				}
-				c, err2 := k8s.GetClient().GetLeaseHolder()
-				if err2 != nil {
-					utils.PrintLog("fatal", utils.LogLine{Error: err2.Error(), Message: "lease"})
-				}
-				for {
-					s := <-c
-					if s == *utils.GetLocalIP() {
-						s = "127.0.0.1"
-					}
-					utils.PrintLog("info", utils.LogLine{Result: fmt.Sprintf("new leader detected '%v'", s), Message: "nats"})
-					err2 = nats.GetPublisher().SetJetStreamContext("nats://" + s + ":4222")
-					if err2 != nil {
-						utils.PrintLog("error", utils.LogLine{Error: err2.Error(), Message: "nats"})
+			c, err := leaderelection.Start()
+			if err != nil {
+				utils.PrintLog("error", utils.LogLine{Error: err.Error(), Result: "the leader election is disabled", Message: "leader-election"})
+			} else {
+				go func() {
+					identity := leaderelection.GetIdentity()
+					for {
+						s := <-c
+						if config.NATS.URL != "" {
+							utils.PrintLog("info", utils.LogLine{Result: fmt.Sprintf("new leader detected '%v'", s), Message: "leader-election"})
+							continue
+						}
+						if s == identity {
+							s = "127.0.0.1"
+						}
+						utils.PrintLog("info", utils.LogLine{Result: fmt.Sprintf("new leader detected '%v'", s), Message: "nats"})
+						err2 := nats.GetPublisher().SetJetStreamContext("nats://" + s + ":4222")
+						if err2 != nil {
+							utils.PrintLog("error", utils.LogLine{Error: err2.Error(), Message: "nats"})
+						}
 					}
-				}
-			}()
+				}()
+			}
 		}
 
 		// start the consumer for the actionners
@@ -243,6 +264,8 @@ var serverCmd = &cobra.Command{
 			utils.PrintLog("fatal", utils.LogLine{Error: err.Error(), Message: "nats"})
 		}
 		go actionners.StartConsumer(c)
+		go actionners.StartApprovalsReaper()
+		go actionners.StartReconciler()
 
 		utils.PrintLog("info", utils.LogLine{Result: fmt.Sprintf("Falco Talon is up and listening on %s:%d", config.ListenAddress, config.ListenPort), Message: "http"})
 
@@ -276,6 +299,16 @@ func newHTTPHandler() http.Handler {
 
 	handleFunc("/", handler.MainHandler)
 	handleFunc("/healthz", handler.HealthHandler)
+	handleFunc("GET /api/v1/events/{trace_id}", handler.RequireToken(handler.EventHandler))
+	handleFunc("GET /api/v1/actions", handler.RequireToken(handler.ActionsHandler))
+	handleFunc("GET /api/v1/approvals", handler.RequireToken(handler.ApprovalsHandler))
+	handleFunc("GET /api/v1/approvals/{id}/{decision}", handler.ApprovalPageHandler)
+	handleFunc("POST /api/v1/approvals/{id}/{decision}", handler.ApprovalHandler)
+	handleFunc("POST /api/v1/rollbacks/{trace_id}", handler.RequireToken(handler.RollbackHandler))
+	handleFunc("GET /api/v1/rollbacks/{trace_id}", handler.RequireToken(handler.RollbackStatusHandler))
+	handleFunc("GET /api/v1/deadletters", handler.RequireToken(handler.DeadLettersHandler))
+	handleFunc("GET /api/v1/deadletters/{id}", handler.RequireToken(handler.DeadLetterHandler))
+	handleFunc("POST /api/v1/deadletters/{id}/redrive", handler.RequireToken(handler.RedriveHandler))
 
 	otelHandler := otelhttp.NewHandler(
 		mux,
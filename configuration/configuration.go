@@ -17,12 +17,32 @@ const (
 	defaultPrintAllEvents               bool   = false
 	defaultDeduplicationLeaderElection  bool   = true
 	defaultDeduplicationTimeWindow      int    = 5
+	defaultDeduplicationKey             string = "output"
+	defaultDeduplicationLeaseName       string = "falco-talon"
+	defaultDeduplicationLeaseDuration   int    = 4
 	defaultOtelCollectorTracesEnabled   bool   = false
 	defaultOtelCollectorMetricsEnabled  bool   = false
 	defaultOtelCollectorEndpoint        string = "localhost"
 	defaultOtelCollectorUseInsecureGrpc bool   = false
 	defaultOtelCollectorPort            int    = 4317
 	defaultOtelCollectorGRPCTimeout            = 10
+	defaultAuditEnabled                 bool   = false
+	defaultAuditFile                    string = "/var/lib/falco-talon/audit.jsonl"
+	defaultAuditMaxSizeMB               int    = 100
+	defaultAuditMaxFiles                int    = 5
+	defaultAPIHistorySize               int    = 1000
+	defaultApprovalExpirationMinutes    int    = 60
+	defaultWorkers                      int    = 1
+	defaultDeadLettersMaxAgeHours       int    = 168
+	defaultNATSStreamName               string = "EVENTS"
+	defaultNATSStreamReplicas           int    = 1
+	defaultNATSStorage                  string = "memory"
+	defaultNATSStoreDir                 string = "/var/lib/falco-talon/nats"
+	defaultNATSDurableName              string = "falco-talon"
+	defaultNATSAckAfterProcessing       bool   = false
+	defaultNATSAckWaitSeconds           int    = 60
+	defaultNATSMaxDeliver               int    = 3
+	defaultNATSMaxInFlight              int    = 100
 )
 
 type Otel struct {
@@ -44,15 +64,77 @@ type Configuration struct {
 	RulesFiles       []string                  `mapstructure:"rules_files"`
 	DefaultNotifiers []string                  `mapstructure:"default_notifiers"`
 	Otel             Otel                      `mapstructure:"otel"`
+	Audit            Audit                     `mapstructure:"audit"`
+	API              API                       `mapstructure:"api"`
+	Approval         Approval                  `mapstructure:"approval"`
+	DeadLetters      DeadLetters               `mapstructure:"dead_letters"`
 	Deduplication    deduplication             `mapstructure:"deduplication"`
+	NATS             NATS                      `mapstructure:"nats"`
 	ListenPort       int                       `mapstructure:"listen_port"`
 	WatchRules       bool                      `mapstructure:"watch_rules"`
+	Workers          int                       `mapstructure:"workers"`
 	PrintAllEvents   bool                      `mapstructure:"print_all_events"`
 }
 
 type deduplication struct {
-	LeaderElection    bool `mapstructure:"leader_election"`
-	TimeWindowSeconds int  `mapstructure:"time_window_seconds"`
+	Identity             string   `mapstructure:"identity"`
+	LeaseName            string   `mapstructure:"lease_name"`
+	LeaseNamespace       string   `mapstructure:"lease_namespace"`
+	Key                  []string `mapstructure:"key"`
+	Peers                []string `mapstructure:"peers"`
+	LeaseDurationSeconds int      `mapstructure:"lease_duration_seconds"`
+	LeaderElection       bool     `mapstructure:"leader_election"`
+	TimeWindowSeconds    int      `mapstructure:"time_window_seconds"`
+}
+
+type Audit struct {
+	File      string `mapstructure:"file"`
+	MaxSizeMB int    `mapstructure:"max_size_mb"`
+	MaxFiles  int    `mapstructure:"max_files"`
+	Enabled   bool   `mapstructure:"enabled"`
+}
+
+type API struct {
+	Token       string `mapstructure:"token"`
+	HistorySize int    `mapstructure:"history_size"`
+}
+
+type Approval struct {
+	URL               string `mapstructure:"url"`
+	ExpirationMinutes int    `mapstructure:"expiration_minutes"`
+}
+
+type DeadLetters struct {
+	MaxAgeHours int `mapstructure:"max_age_hours"`
+}
+
+type NATS struct {
+	TLS                NATSTLS `mapstructure:"tls"`
+	URL                string  `mapstructure:"url"`
+	User               string  `mapstructure:"user"`
+	Password           string  `mapstructure:"password"`
+	Token              string  `mapstructure:"token"`
+	CredsFile          string  `mapstructure:"creds_file"`
+	NKeySeedFile       string  `mapstructure:"nkey_seed_file"`
+	JWT                string  `mapstructure:"jwt"`
+	NKeySeed           string  `mapstructure:"nkey_seed"`
+	StreamName         string  `mapstructure:"stream_name"`
+	StreamReplicas     int     `mapstructure:"stream_replicas"`
+	Storage            string  `mapstructure:"storage"`
+	StoreDir           string  `mapstructure:"store_dir"`
+	DurableName        string  `mapstructure:"durable_name"`
+	MaxAgeSeconds      int     `mapstructure:"max_age_seconds"`
+	AckWaitSeconds     int     `mapstructure:"ack_wait_seconds"`
+	MaxDeliver         int     `mapstructure:"max_deliver"`
+	MaxInFlight        int     `mapstructure:"max_in_flight"`
+	AckAfterProcessing bool    `mapstructure:"ack_after_processing"`
+}
+
+type NATSTLS struct {
+	CAFile             string `mapstructure:"ca_file"`
+	CertFile           string `mapstructure:"cert_file"`
+	KeyFile            string `mapstructure:"key_file"`
+	InsecureSkipVerify bool   `mapstructure:"insecure_skip_verify"`
 }
 
 type AwsConfig struct {
@@ -83,17 +165,44 @@ func CreateConfiguration(configFile string) *Configuration {
 	v.SetDefault("rules_files", []string{defaultRulesFile})
 	v.SetDefault("kubeconfig", "")
 	v.SetDefault("log_format", "color")
+	v.SetDefault("workers", defaultWorkers)
 	v.SetDefault("default_notifiers", []string{})
 	v.SetDefault("watch_rules", defaultWatchRules)
 	v.SetDefault("print_all_events", defaultPrintAllEvents)
 	v.SetDefault("deduplication.leader_election", defaultDeduplicationLeaderElection)
 	v.SetDefault("deduplication.time_window_seconds", defaultDeduplicationTimeWindow)
+	v.SetDefault("deduplication.key", []string{defaultDeduplicationKey})
+	v.SetDefault("deduplication.identity", "")
+	v.SetDefault("deduplication.peers", []string{})
+	v.SetDefault("deduplication.lease_name", defaultDeduplicationLeaseName)
+	v.SetDefault("deduplication.lease_namespace", "")
+	v.SetDefault("deduplication.lease_duration_seconds", defaultDeduplicationLeaseDuration)
 	v.SetDefault("otel.traces_enabled", defaultOtelCollectorTracesEnabled)
 	v.SetDefault("otel.metrics_enabled", defaultOtelCollectorMetricsEnabled)
 	v.SetDefault("otel.collector_endpoint", defaultOtelCollectorEndpoint)
 	v.SetDefault("otel.collector_port", defaultOtelCollectorPort)
 	v.Set("otel.timeout", defaultOtelCollectorGRPCTimeout)
 	v.SetDefault("otel.collector_use_insecure_grpc", defaultOtelCollectorUseInsecureGrpc)
+	v.SetDefault("audit.enabled", defaultAuditEnabled)
+	v.SetDefault("audit.file", defaultAuditFile)
+	v.SetDefault("audit.max_size_mb", defaultAuditMaxSizeMB)
+	v.SetDefault("audit.max_files", defaultAuditMaxFiles)
+	v.SetDefault("api.history_size", defaultAPIHistorySize)
+	v.SetDefault("api.token", "")
+	v.SetDefault("approval.url", "")
+	v.SetDefault("approval.expiration_minutes", defaultApprovalExpirationMinutes)
+	v.SetDefault("dead_letters.max_age_hours", defaultDeadLettersMaxAgeHours)
+	v.SetDefault("nats.url", "")
+	v.SetDefault("nats.stream_name", defaultNATSStreamName)
+	v.SetDefault("nats.stream_replicas", defaultNATSStreamReplicas)
+	v.SetDefault("nats.storage", defaultNATSStorage)
+	v.SetDefault("nats.store_dir", defaultNATSStoreDir)
+	v.SetDefault("nats.durable_name", defaultNATSDurableName)
+	v.SetDefault("nats.max_age_seconds", 0)
+	v.SetDefault("nats.ack_after_processing", defaultNATSAckAfterProcessing)
+	v.SetDefault("nats.ack_wait_seconds", defaultNATSAckWaitSeconds)
+	v.SetDefault("nats.max_deliver", defaultNATSMaxDeliver)
+	v.SetDefault("nats.max_in_flight", defaultNATSMaxInFlight)
 	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
 	v.AutomaticEnv()
 
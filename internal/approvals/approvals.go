@@ -0,0 +1,120 @@
+package approvals
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+	"time"
+
+	natsgo "github.com/nats-io/nats.go"
+
+	"github.com/falcosecurity/falco-talon/internal/events"
+	"github.com/falcosecurity/falco-talon/internal/nats"
+)
+
+// Pending is an action waiting for an approval
+type Pending struct {
+	CreatedAt time.Time     `json:"created_at"`
+	ExpiresAt time.Time     `json:"expires_at"`
+	Event     *events.Event `json:"event"`
+	ID        string        `json:"id"`
+	Rule      string        `json:"rule"`
+	Action    string        `json:"action"`
+	Actionner string        `json:"actionner"`
+	// Token is the secret of the links to approve or deny the action, it's never returned by the API
+	Token string `json:"token,omitempty"`
+}
+
+const bucketName string = "APPROVALS"
+
+var ErrNotFound = errors.New("pending action not found")
+
+func getBucket() (natsgo.KeyValue, error) {
+	return nats.GetPublisher().GetKeyValue(bucketName)
+}
+
+// Add stores a pending action
+func Add(p *Pending) error {
+	kv, err := getBucket()
+	if err != nil {
+		return err
+	}
+	b, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+	_, err = kv.Create(p.ID, b)
+	return err
+}
+
+// Get returns a pending action
+func Get(id string) (*Pending, error) {
+	kv, err := getBucket()
+	if err != nil {
+		return nil, err
+	}
+	entry, err := kv.Get(id)
+	if err != nil {
+		if errors.Is(err, natsgo.ErrKeyNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	var p Pending
+	if err := json.Unmarshal(entry.Value(), &p); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// Take removes a pending action from the store and returns it, only one caller can take a same pending action
+func Take(id string) (*Pending, error) {
+	kv, err := getBucket()
+	if err != nil {
+		return nil, err
+	}
+	entry, err := kv.Get(id)
+	if err != nil {
+		if errors.Is(err, natsgo.ErrKeyNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	var p Pending
+	if err := json.Unmarshal(entry.Value(), &p); err != nil {
+		return nil, err
+	}
+	if err := kv.Delete(id, natsgo.LastRevision(entry.Revision())); err != nil {
+		return nil, ErrNotFound
+	}
+	return &p, nil
+}
+
+// List returns the pending actions, from the oldest to the newest
+func List() ([]*Pending, error) {
+	kv, err := getBucket()
+	if err != nil {
+		return nil, err
+	}
+	pendings := make([]*Pending, 0)
+	keys, err := kv.Keys()
+	if err != nil {
+		if errors.Is(err, natsgo.ErrNoKeysFound) {
+			return pendings, nil
+		}
+		return nil, err
+	}
+	for _, i := range keys {
+		entry, err := kv.Get(i)
+		if err != nil {
+			continue
+		}
+		var p Pending
+		if err := json.Unmarshal(entry.Value(), &p); err != nil {
+			continue
+		}
+		pendings = append(pendings, &p)
+	}
+	sort.Slice(pendings, func(i, j int) bool { return pendings[i].CreatedAt.Before(pendings[j].CreatedAt) })
+	return pendings, nil
+}
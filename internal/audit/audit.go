@@ -0,0 +1,235 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/falcosecurity/falco-talon/configuration"
+	"github.com/falcosecurity/falco-talon/internal/events"
+	"github.com/falcosecurity/falco-talon/utils"
+)
+
+// Record is an entry of the audit log
+type Record struct {
+	FalcoEvent *events.Event `json:"falco_event,omitempty"`
+	utils.LogLine
+}
+
+// Filter selects the records returned by Query, the empty fields are ignored
+type Filter struct {
+	Since     time.Time
+	Until     time.Time
+	TraceID   string
+	Rule      string
+	Namespace string
+}
+
+type auditLog struct {
+	file     *os.File
+	path     string
+	size     int64
+	maxSize  int64
+	maxFiles int
+	mu       sync.Mutex
+}
+
+const (
+	matchStr        string = "match"
+	actionStr       string = "action"
+	outputStr       string = "output"
+	notificationStr string = "notification"
+	approvalStr     string = "approval"
+	rollbackStr     string = "rollback"
+	ttlStr          string = "ttl"
+)
+
+var log *auditLog
+
+func Init() error {
+	config := configuration.GetConfiguration()
+	if !config.Audit.Enabled {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(config.Audit.File), 0750); err != nil {
+		return err
+	}
+
+	l := &auditLog{
+		path:     config.Audit.File,
+		maxSize:  int64(config.Audit.MaxSizeMB) * 1024 * 1024,
+		maxFiles: config.Audit.MaxFiles,
+	}
+	if err := l.open(); err != nil {
+		return err
+	}
+	log = l
+
+	utils.AddLogHook(func(_ string, line utils.LogLine) {
+		switch line.Message {
+		case actionStr, outputStr, notificationStr, approvalStr, rollbackStr, ttlStr:
+			Add(Record{LogLine: line})
+		}
+	})
+
+	utils.PrintLog("info", utils.LogLine{Message: "init", Category: "audit", Status: utils.SuccessStr})
+	return nil
+}
+
+// RecordMatch adds the match of an event by a rule in the audit log
+func RecordMatch(event *events.Event, line utils.LogLine) {
+	line.Message = matchStr
+	Add(Record{LogLine: line, FalcoEvent: event})
+}
+
+// Add appends a record to the audit log, if enabled
+func Add(record Record) {
+	if log == nil {
+		return
+	}
+	if record.Time == "" {
+		record.Time = time.Now().UTC().Format(time.RFC3339Nano)
+	}
+	b, err := json.Marshal(record)
+	if err != nil {
+		return
+	}
+	if err := log.write(append(b, '\n')); err != nil {
+		fmt.Fprintf(os.Stderr, "audit: %v\n", err)
+	}
+}
+
+func (l *auditLog) open() error {
+	f, err := os.OpenFile(l.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	s, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	l.file = f
+	l.size = s.Size()
+	return nil
+}
+
+func (l *auditLog) write(b []byte) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.maxSize > 0 && l.size+int64(len(b)) > l.maxSize {
+		if err := l.rotate(); err != nil {
+			return err
+		}
+	}
+	n, err := l.file.Write(b)
+	l.size += int64(n)
+	return err
+}
+
+// rotate renames the current file into <file>.1, the previous ones are shifted and the oldest is removed
+func (l *auditLog) rotate() error {
+	if err := l.file.Close(); err != nil {
+		return err
+	}
+	if l.maxFiles > 0 {
+		_ = os.Remove(fmt.Sprintf("%v.%v", l.path, l.maxFiles))
+		for i := l.maxFiles - 1; i > 0; i-- {
+			_ = os.Rename(fmt.Sprintf("%v.%v", l.path, i), fmt.Sprintf("%v.%v", l.path, i+1))
+		}
+		if err := os.Rename(l.path, l.path+".1"); err != nil {
+			return err
+		}
+	} else if err := os.Remove(l.path); err != nil {
+		return err
+	}
+	return l.open()
+}
+
+// Files returns the current file of the audit log and its rotated ones, from the oldest to the newest
+func Files(path string, maxFiles int) []string {
+	var files []string
+	for i := maxFiles; i > 0; i-- {
+		f := fmt.Sprintf("%v.%v", path, i)
+		if _, err := os.Stat(f); err == nil {
+			files = append(files, f)
+		}
+	}
+	if _, err := os.Stat(path); err == nil {
+		files = append(files, path)
+	}
+	return files
+}
+
+// Query returns the records of the files matching the filter
+func Query(files []string, filter Filter) ([]Record, error) {
+	if len(files) == 0 {
+		return nil, errors.New("no audit log found")
+	}
+	records := make([]Record, 0)
+	for _, i := range files {
+		f, err := os.Open(i)
+		if err != nil {
+			return nil, err
+		}
+		scanner := bufio.NewScanner(f)
+		scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+		for scanner.Scan() {
+			var r Record
+			if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+				continue
+			}
+			if filter.match(&r) {
+				records = append(records, r)
+			}
+		}
+		err = scanner.Err()
+		f.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return records, nil
+}
+
+func (filter *Filter) match(r *Record) bool {
+	if filter.TraceID != "" && r.TraceID != filter.TraceID {
+		return false
+	}
+	if filter.Rule != "" && r.Rule != filter.Rule {
+		return false
+	}
+	if filter.Namespace != "" && r.getNamespace() != filter.Namespace {
+		return false
+	}
+	if !filter.Since.IsZero() || !filter.Until.IsZero() {
+		t, err := time.Parse(time.RFC3339Nano, r.Time)
+		if err != nil {
+			return false
+		}
+		if !filter.Since.IsZero() && t.Before(filter.Since) {
+			return false
+		}
+		if !filter.Until.IsZero() && t.After(filter.Until) {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *Record) getNamespace() string {
+	if n := r.Objects["namespace"]; n != "" {
+		return n
+	}
+	if r.FalcoEvent != nil {
+		return r.FalcoEvent.GetNamespaceName()
+	}
+	return ""
+}
@@ -7,15 +7,15 @@ import (
 	"github.com/falcosecurity/falco-talon/internal/events"
 )
 
-func GetAwsContext(_ *events.Event) (map[string]any, error) {
+func GetAwsContext(ctx context.Context, _ *events.Event) (map[string]any, error) {
 	imdsClient := aws.GetImdsClient()
 
-	info, err := imdsClient.GetIAMInfo(context.Background(), nil)
+	info, err := imdsClient.GetIAMInfo(ctx, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	region, err := imdsClient.GetRegion(context.Background(), nil)
+	region, err := imdsClient.GetRegion(ctx, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -19,7 +19,7 @@ import (
 func GetContext(actx context.Context, source string, event *events.Event) (map[string]any, error) {
 	tracer := traces.GetTracer()
 
-	_, span := tracer.Start(actx, "context",
+	ctx, span := tracer.Start(actx, "context",
 		oteltrace.WithAttributes(attribute.String("context.source", source)),
 	)
 	defer span.End()
@@ -29,9 +29,9 @@ func GetContext(actx context.Context, source string, event *events.Event) (map[s
 
 	switch source {
 	case "aws":
-		context, err = aws.GetAwsContext(event)
+		context, err = aws.GetAwsContext(ctx, event)
 	case "k8snode":
-		context, err = kubernetes.GetNodeContext(event)
+		context, err = kubernetes.GetNodeContext(ctx, event)
 	default:
 		err = fmt.Errorf("unknown context '%v'", source)
 	}
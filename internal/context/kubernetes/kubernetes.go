@@ -1,20 +1,22 @@
 package kubernetes
 
 import (
+	"context"
+
 	"github.com/falcosecurity/falco-talon/internal/events"
 	kubernetes "github.com/falcosecurity/falco-talon/internal/kubernetes/client"
 )
 
-func GetNodeContext(event *events.Event) (map[string]any, error) {
+func GetNodeContext(ctx context.Context, event *events.Event) (map[string]any, error) {
 	podName := event.GetPodName()
 	namespace := event.GetNamespaceName()
 
 	client := kubernetes.GetClient()
-	pod, err := client.GetPod(podName, namespace)
+	pod, err := client.GetPod(ctx, podName, namespace)
 	if err != nil {
 		return nil, err
 	}
-	node, err := client.GetNodeFromPod(pod)
+	node, err := client.GetNodeFromPod(ctx, pod)
 	if err != nil {
 		return nil, err
 	}
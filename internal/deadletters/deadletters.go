@@ -0,0 +1,132 @@
+package deadletters
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+	"time"
+
+	natsgo "github.com/nats-io/nats.go"
+
+	"github.com/falcosecurity/falco-talon/configuration"
+	"github.com/falcosecurity/falco-talon/internal/events"
+	"github.com/falcosecurity/falco-talon/internal/nats"
+)
+
+// Entry is an execution of an action which failed permanently
+type Entry struct {
+	Time      time.Time     `json:"time"`
+	Event     *events.Event `json:"event"`
+	ID        string        `json:"id"`
+	Rule      string        `json:"rule"`
+	Action    string        `json:"action"`
+	Actionner string        `json:"actionner"`
+	Error     string        `json:"error"`
+	Status    string        `json:"status"`
+	Attempts  int           `json:"attempts"`
+	// Redrives is the number of redrives of the action before this failure
+	Redrives int `json:"redrives"`
+	// Approved is true if the action required an approval and was approved
+	Approved bool `json:"approved"`
+}
+
+const streamName string = "DEADLETTERS"
+
+var ErrNotFound = errors.New("dead letter not found")
+
+func getStream() (*nats.Client, *natsgo.StreamInfo, error) {
+	client := nats.GetPublisher()
+	stream, err := client.GetStream(streamName, time.Duration(configuration.GetConfiguration().DeadLetters.MaxAgeHours)*time.Hour)
+	if err != nil {
+		return nil, nil, err
+	}
+	return client, stream, nil
+}
+
+// Add stores a failed execution
+func Add(e *Entry) error {
+	client, _, err := getStream()
+	if err != nil {
+		return err
+	}
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	_, err = client.Publish(streamName+"."+e.ID, b)
+	return err
+}
+
+// Get returns the failed execution with the given id
+func Get(id string) (*Entry, error) {
+	client, _, err := getStream()
+	if err != nil {
+		return nil, err
+	}
+	m, err := client.GetLastMsg(streamName, streamName+"."+id)
+	if err != nil {
+		if errors.Is(err, natsgo.ErrMsgNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	var e Entry
+	if err := json.Unmarshal(m.Data, &e); err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+// Take removes the failed execution with the given id from the stream and returns it,
+// only one caller can take a same failed execution
+func Take(id string) (*Entry, error) {
+	client, _, err := getStream()
+	if err != nil {
+		return nil, err
+	}
+	m, err := client.GetLastMsg(streamName, streamName+"."+id)
+	if err != nil {
+		if errors.Is(err, natsgo.ErrMsgNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	if err := client.DeleteMsg(streamName, m.Sequence); err != nil {
+		return nil, ErrNotFound
+	}
+	var e Entry
+	if err := json.Unmarshal(m.Data, &e); err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+// List returns the failed executions, from the oldest to the newest
+func List() ([]*Entry, error) {
+	client, stream, err := getStream()
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]*Entry, 0)
+	if stream.State.Msgs == 0 {
+		return entries, nil
+	}
+	for seq := stream.State.FirstSeq; seq <= stream.State.LastSeq; seq++ {
+		m, err := client.GetMsg(streamName, seq)
+		if err != nil {
+			if errors.Is(err, natsgo.ErrMsgNotFound) {
+				continue // deleted message
+			}
+			return nil, err
+		}
+		var e Entry
+		if err := json.Unmarshal(m.Data, &e); err != nil {
+			continue
+		}
+		entries = append(entries, &e)
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Time.Before(entries[j].Time)
+	})
+	return entries, nil
+}
@@ -25,12 +25,20 @@ type Event struct {
 
 const (
 	trimPrefix = "(?i)^\\d{2}:\\d{2}:\\d{2}\\.\\d{9}\\:\\ (Debug|Info|Informational|Notice|Warning|Error|Critical|Alert|Emergency)"
+	template   = `\$\{([^{}]+)\}`
+)
+
+const (
+	outputFieldsPrefix string = "output_fields"
+	contextPrefix      string = "context"
 )
 
 var regTrimPrefix *regexp.Regexp
+var regTemplate *regexp.Regexp
 
 func init() {
 	regTrimPrefix = regexp.MustCompile(trimPrefix)
+	regTemplate = regexp.MustCompile(template)
 }
 
 func DecodeEvent(payload io.Reader) (*Event, error) {
@@ -103,6 +111,16 @@ func (event *Event) GetRemoteIP() string {
 	return ""
 }
 
+func (event *Event) GetRemoteName() string {
+	if i := event.OutputFields["fd.rip.name"]; i != nil {
+		return i.(string)
+	}
+	if i := event.OutputFields["fd.sip.name"]; i != nil {
+		return i.(string)
+	}
+	return ""
+}
+
 func (event *Event) GetRemotePort() string {
 	if i := event.OutputFields["fd.rport"]; i != nil {
 		return i.(string)
@@ -123,6 +141,94 @@ func (event *Event) GetRemoteProtocol() string {
 	return ""
 }
 
+// GetField returns the value of a field of the event, or nil if it doesn't exist.
+// The output fields and the context elements can be referenced as output_fields[key],
+// output_fields.key, context[key], context.key or directly by their key.
+func (event *Event) GetField(field string) any {
+	field = strings.TrimSpace(field)
+	switch field {
+	case "rule":
+		return event.Rule
+	case "priority":
+		return event.Priority
+	case "source":
+		return event.Source
+	case "hostname":
+		return event.Hostname
+	case "output":
+		return event.Output
+	case "trace_id":
+		return event.TraceID
+	case "time":
+		return event.Time.Format(time.RFC3339)
+	case "tags":
+		var tags []string
+		for _, i := range event.Tags {
+			tags = append(tags, fmt.Sprintf("%v", i))
+		}
+		return strings.Join(tags, ",")
+	}
+	if key, ok := getPrefixedKey(field, outputFieldsPrefix); ok {
+		return event.OutputFields[key]
+	}
+	if key, ok := getPrefixedKey(field, contextPrefix); ok {
+		return event.Context[key]
+	}
+	if v := event.OutputFields[field]; v != nil {
+		return v
+	}
+	return event.Context[field]
+}
+
+func getPrefixedKey(field, prefix string) (string, bool) {
+	if strings.HasPrefix(field, prefix+"[") && strings.HasSuffix(field, "]") {
+		return strings.TrimSuffix(strings.TrimPrefix(field, prefix+"["), "]"), true
+	}
+	if strings.HasPrefix(field, prefix+".") {
+		return strings.TrimPrefix(field, prefix+"."), true
+	}
+	return "", false
+}
+
+// Interpolate replaces the ${field} placeholders in s by the values of the fields of the event,
+// the placeholders of unknown fields are kept as is
+func (event *Event) Interpolate(s string) string {
+	return event.InterpolateWith(s, nil)
+}
+
+// InterpolateWith is like Interpolate, the extra fields are used before the fields of the event
+func (event *Event) InterpolateWith(s string, extra map[string]string) string {
+	return event.InterpolateEscaped(s, extra, nil)
+}
+
+// InterpolateEscaped is like InterpolateWith, the substituted values are transformed by the escape function if it's set
+func (event *Event) InterpolateEscaped(s string, extra map[string]string, escape func(string) string) string {
+	return regTemplate.ReplaceAllStringFunc(s, func(m string) string {
+		field := strings.TrimSpace(regTemplate.FindStringSubmatch(m)[1])
+		v, ok := extra[field]
+		if !ok {
+			f := event.GetField(field)
+			if f == nil {
+				return m
+			}
+			v = fmt.Sprintf("%v", f)
+		}
+		if escape != nil {
+			return escape(v)
+		}
+		return v
+	})
+}
+
+// CheckTemplate returns an error if s contains a malformed ${field} placeholder
+func CheckTemplate(s string) error {
+	r := regTemplate.ReplaceAllString(s, "")
+	if strings.Contains(r, "${") {
+		return fmt.Errorf("malformed placeholder in '%v'", s)
+	}
+	return nil
+}
+
 func (event *Event) AddContext(elements map[string]any) {
 	if event.Context == nil {
 		event.Context = make(map[string]any)
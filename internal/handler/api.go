@@ -0,0 +1,246 @@
+package handler
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"html/template"
+	"net/http"
+	"strings"
+
+	"github.com/falcosecurity/falco-talon/actionners"
+	"github.com/falcosecurity/falco-talon/configuration"
+	"github.com/falcosecurity/falco-talon/internal/approvals"
+	"github.com/falcosecurity/falco-talon/internal/deadletters"
+	"github.com/falcosecurity/falco-talon/internal/history"
+	"github.com/falcosecurity/falco-talon/internal/rollbacks"
+	"github.com/falcosecurity/falco-talon/utils"
+)
+
+// EventHandler returns the event with the given trace id, the matched rules, the executed actions and their outputs
+func EventHandler(w http.ResponseWriter, r *http.Request) {
+	record, ok := history.GetEvent(r.PathValue("trace_id"))
+	if !ok {
+		http.Error(w, "Event not found", http.StatusNotFound)
+		return
+	}
+	writeJSON(w, record)
+}
+
+// ActionsHandler returns the recent executions of actions, filtered by the 'rule', 'status' and 'since' query parameters
+func ActionsHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	since, err := utils.ParseTime(query.Get("since"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	writeJSON(w, history.GetActions(history.Filter{
+		Rule:   query.Get("rule"),
+		Status: query.Get("status"),
+		Since:  since,
+	}))
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Add("Content-Type", "application/json")
+	_, _ = w.Write(b)
+}
+
+// RequireToken allows the requests only with the API token in the 'Authorization: Bearer <token>' header,
+// the endpoint is disabled if the token isn't set
+func RequireToken(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if configuration.GetConfiguration().API.Token == "" {
+			http.Error(w, "Forbidden, the API token isn't set", http.StatusForbidden)
+			return
+		}
+		if !hasAPIToken(r) {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next(w, r)
+	}
+}
+
+func hasAPIToken(r *http.Request) bool {
+	token := configuration.GetConfiguration().API.Token
+	if token == "" {
+		return false
+	}
+	v, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return ok && subtle.ConstantTimeCompare([]byte(v), []byte(token)) == 1
+}
+
+// ApprovalsHandler returns the actions waiting for an approval
+func ApprovalsHandler(w http.ResponseWriter, _ *http.Request) {
+	pendings, err := approvals.List()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	for _, i := range pendings {
+		i.Token = ""
+	}
+	writeJSON(w, pendings)
+}
+
+var approvalPage = template.Must(template.New("approval").Parse(`<!DOCTYPE html>
+<html>
+<head><title>Falco Talon</title></head>
+<body>
+<p>Rule: {{ .Pending.Rule }}</p>
+<p>Action: {{ .Pending.Action }} ({{ .Pending.Actionner }})</p>
+<p>Event: {{ .Pending.Event.Output }}</p>
+<p>Expires at: {{ .Pending.ExpiresAt }}</p>
+<form method="post">
+<input type="hidden" name="token" value="{{ .Token }}">
+<button type="submit">{{ .Decision }}</button>
+</form>
+</body>
+</html>
+`))
+
+// getAuthorizedPending returns the pending action if the request contains its token or the API token
+func getAuthorizedPending(w http.ResponseWriter, r *http.Request) *approvals.Pending {
+	if d := r.PathValue("decision"); d != "approve" && d != "deny" {
+		http.Error(w, "Unknown decision, use 'approve' or 'deny'", http.StatusBadRequest)
+		return nil
+	}
+	p, err := approvals.Get(r.PathValue("id"))
+	if errors.Is(err, approvals.ErrNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return nil
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return nil
+	}
+	token := r.FormValue("token")
+	if !hasAPIToken(r) && (p.Token == "" || subtle.ConstantTimeCompare([]byte(token), []byte(p.Token)) != 1) {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return nil
+	}
+	return p
+}
+
+// ApprovalPageHandler renders the page to confirm the approval or the denial of a pending action,
+// it changes nothing, to not be triggered by the previews of the links
+func ApprovalPageHandler(w http.ResponseWriter, r *http.Request) {
+	p := getAuthorizedPending(w, r)
+	if p == nil {
+		return
+	}
+	w.Header().Add("Content-Type", "text/html; charset=utf-8")
+	_ = approvalPage.Execute(w, map[string]any{
+		"Pending":  p,
+		"Token":    r.FormValue("token"),
+		"Decision": r.PathValue("decision"),
+	})
+}
+
+// ApprovalHandler approves or denies a pending action, the request requires the token of the pending action
+// or the API token
+func ApprovalHandler(w http.ResponseWriter, r *http.Request) {
+	p := getAuthorizedPending(w, r)
+	if p == nil {
+		return
+	}
+	var err error
+	var status string
+	switch r.PathValue("decision") {
+	case "approve":
+		err = actionners.Approve(p.ID)
+		status = utils.ApprovedStr
+	case "deny":
+		err = actionners.Deny(p.ID)
+		status = utils.DeniedStr
+	}
+	if errors.Is(err, approvals.ErrNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, map[string]string{"id": p.ID, "status": status})
+}
+
+// RollbackHandler starts the rollback of the changes done by the actions for the event with the given trace id,
+// its progress is returned by RollbackStatusHandler
+func RollbackHandler(w http.ResponseWriter, r *http.Request) {
+	traceID := r.PathValue("trace_id")
+	err := actionners.StartRollback(traceID)
+	if errors.Is(err, rollbacks.ErrNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Add("Location", "/api/v1/rollbacks/"+traceID)
+	w.Header().Add("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	b, _ := json.Marshal(map[string]string{"trace_id": traceID, "status": utils.RunningStr})
+	_, _ = w.Write(b)
+}
+
+// RollbackStatusHandler returns the progress and the results of the last rollback for the given trace id
+func RollbackStatusHandler(w http.ResponseWriter, r *http.Request) {
+	status, err := rollbacks.GetStatus(r.PathValue("trace_id"))
+	if errors.Is(err, rollbacks.ErrStatusNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, status)
+}
+
+// DeadLettersHandler returns the actions which failed permanently
+func DeadLettersHandler(w http.ResponseWriter, _ *http.Request) {
+	entries, err := deadletters.List()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, entries)
+}
+
+// DeadLetterHandler returns the failed action with the given id
+func DeadLetterHandler(w http.ResponseWriter, r *http.Request) {
+	entry, err := deadletters.Get(r.PathValue("id"))
+	if errors.Is(err, deadletters.ErrNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, entry)
+}
+
+// RedriveHandler runs again the failed action with the given id
+func RedriveHandler(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	err := actionners.Redrive(id)
+	if errors.Is(err, deadletters.ErrNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, map[string]string{"id": id, "status": "redriven"})
+}
@@ -18,6 +18,7 @@ import (
 	"github.com/falcosecurity/falco-talon/internal/nats"
 	"github.com/falcosecurity/falco-talon/internal/otlp/metrics"
 	"github.com/falcosecurity/falco-talon/internal/otlp/traces"
+	"github.com/falcosecurity/falco-talon/internal/rules"
 	"github.com/falcosecurity/falco-talon/utils"
 )
 
@@ -80,15 +81,32 @@ func MainHandler(w http.ResponseWriter, r *http.Request) {
 
 	metrics.IncreaseCounter(log)
 
-	hasher := md5.New() //nolint:gosec
-	hasher.Write([]byte(event.Output))
-
-	err = nats.GetPublisher().PublishMsg(ctx, hex.EncodeToString(hasher.Sum(nil)), event.String())
+	err = nats.GetPublisher().PublishMsg(ctx, getDeduplicationID(event), event.String())
 	if err != nil {
 		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
 	}
 }
 
+// getDeduplicationID returns the hash of the fields of the event used as key for the deduplication,
+// the key of the first matching rule with a 'deduplication' setting overrides the one from the configuration
+func getDeduplicationID(event *events.Event) string {
+	key := configuration.GetConfiguration().Deduplication.Key
+	if r := rules.GetRules(); r != nil {
+		for _, i := range *r {
+			if i.GetDeduplicationKey() != nil && i.CompareRule(event) {
+				key = i.GetDeduplicationKey()
+				break
+			}
+		}
+	}
+
+	hasher := md5.New() //nolint:gosec
+	for _, i := range key {
+		hasher.Write([]byte(fmt.Sprintf("%v=%v\n", i, event.GetField(i))))
+	}
+	return hex.EncodeToString(hasher.Sum(nil))
+}
+
 // HealthHandler is a simple handler to test if daemon is UP.
 func HealthHandler(w http.ResponseWriter, _ *http.Request) {
 	w.Header().Add("Content-Type", "application/json")
@@ -0,0 +1,171 @@
+package history
+
+import (
+	"sync"
+	"time"
+
+	"github.com/falcosecurity/falco-talon/configuration"
+	"github.com/falcosecurity/falco-talon/internal/events"
+	"github.com/falcosecurity/falco-talon/utils"
+)
+
+// Record gathers everything done by Falco Talon for an event
+type Record struct {
+	Event   *events.Event   `json:"event"`
+	Matches []utils.LogLine `json:"matches"`
+	Actions []utils.LogLine `json:"actions"`
+	Outputs []utils.LogLine `json:"outputs"`
+}
+
+// Filter selects the actions returned by GetActions, the empty fields are ignored
+type Filter struct {
+	Since  time.Time
+	Rule   string
+	Status string
+}
+
+type store struct {
+	records map[string]*Record
+	order   []string
+	size    int
+	sync.RWMutex
+}
+
+const (
+	matchStr  string = "match"
+	actionStr string = "action"
+	outputStr string = "output"
+)
+
+var s *store
+
+func Init() {
+	s = &store{
+		records: make(map[string]*Record),
+		size:    configuration.GetConfiguration().API.HistorySize,
+	}
+
+	utils.AddLogHook(func(_ string, line utils.LogLine) {
+		switch line.Message {
+		case actionStr, outputStr:
+			add(line)
+		}
+	})
+}
+
+// RecordMatch adds the match of an event by a rule in the history,
+// the oldest events are removed once the size of the history is reached
+func RecordMatch(event *events.Event, line utils.LogLine) {
+	if s == nil || event == nil || event.TraceID == "" {
+		return
+	}
+
+	s.Lock()
+	defer s.Unlock()
+
+	r, ok := s.records[event.TraceID]
+	if !ok {
+		r = &Record{
+			Event:   event,
+			Matches: make([]utils.LogLine, 0),
+			Actions: make([]utils.LogLine, 0),
+			Outputs: make([]utils.LogLine, 0),
+		}
+		s.records[event.TraceID] = r
+		s.order = append(s.order, event.TraceID)
+		for len(s.order) > s.size && s.size > 0 {
+			delete(s.records, s.order[0])
+			s.order = s.order[1:]
+		}
+	}
+	line.Message = matchStr
+	r.Matches = append(r.Matches, setTime(line))
+}
+
+func add(line utils.LogLine) {
+	if s == nil || line.TraceID == "" {
+		return
+	}
+
+	s.Lock()
+	defer s.Unlock()
+
+	r, ok := s.records[line.TraceID]
+	if !ok {
+		return
+	}
+	switch line.Message {
+	case actionStr:
+		r.Actions = append(r.Actions, setTime(line))
+	case outputStr:
+		r.Outputs = append(r.Outputs, setTime(line))
+	}
+}
+
+// GetEvent returns the record of an event by its trace id
+func GetEvent(traceID string) (Record, bool) {
+	if s == nil {
+		return Record{}, false
+	}
+
+	s.RLock()
+	defer s.RUnlock()
+
+	r, ok := s.records[traceID]
+	if !ok {
+		return Record{}, false
+	}
+	return copyRecord(r), true
+}
+
+// GetActions returns the executions of actions matching the filter, from the oldest to the newest
+func GetActions(filter Filter) []utils.LogLine {
+	actions := make([]utils.LogLine, 0)
+	if s == nil {
+		return actions
+	}
+
+	s.RLock()
+	defer s.RUnlock()
+
+	for _, i := range s.order {
+		for _, j := range s.records[i].Actions {
+			if filter.match(j) {
+				actions = append(actions, j)
+			}
+		}
+	}
+	return actions
+}
+
+func (filter *Filter) match(line utils.LogLine) bool {
+	if filter.Rule != "" && line.Rule != filter.Rule {
+		return false
+	}
+	if filter.Status != "" && line.Status != filter.Status {
+		return false
+	}
+	if !filter.Since.IsZero() {
+		t, err := time.Parse(time.RFC3339Nano, line.Time)
+		if err != nil || t.Before(filter.Since) {
+			return false
+		}
+	}
+	return true
+}
+
+func setTime(line utils.LogLine) utils.LogLine {
+	if line.Time == "" {
+		line.Time = time.Now().UTC().Format(time.RFC3339Nano)
+	}
+	return line
+}
+
+func copyRecord(r *Record) Record {
+	return Record{
+		Event:   r.Event,
+		Matches: append([]utils.LogLine{}, r.Matches...),
+		Actions: append([]utils.LogLine{}, r.Actions...),
+		Outputs: append([]utils.LogLine{}, r.Outputs...),
+	}
+}
@@ -1,6 +1,7 @@
 package checks
 
 import (
+	"context"
 	"errors"
 	"net"
 	"strconv"
@@ -37,7 +38,7 @@ func CheckPodExist(event *events.Event) error {
 	if client == nil {
 		return errors.New("wrong k8s client")
 	}
-	_, err := client.GetPod(event.GetPodName(), event.GetNamespaceName())
+	_, err := client.GetPod(context.Background(), event.GetPodName(), event.GetNamespaceName())
 	return err
 }
 
@@ -87,6 +88,18 @@ func CheckRemoteIP(event *events.Event) error {
 	return nil
 }
 
+func CheckRemoteName(event *events.Event) error {
+	if event.OutputFields["fd.sip.name"] == nil &&
+		event.OutputFields["fd.rip.name"] == nil {
+		return errors.New("missing domain field(s) (fd.sip.name or fd.rip.name)")
+	}
+	if event.GetRemoteName() == "" {
+		return errors.New("empty value for fd.sip.name or fd.rip.name")
+	}
+
+	return nil
+}
+
 func CheckRemotePort(event *events.Event) error {
 	if event.OutputFields["fd.sport"] == nil &&
 		event.OutputFields["fd.rport"] == nil {
@@ -121,6 +134,6 @@ func CheckTargetExist(event *events.Event) error {
 	if client == nil {
 		return errors.New("wrong k8s client")
 	}
-	_, err := client.GetTarget(event.GetTargetResource(), event.GetTargetName(), event.GetTargetNamespace())
+	_, err := client.GetTarget(context.Background(), event.GetTargetResource(), event.GetTargetName(), event.GetTargetNamespace())
 	return err
 }
@@ -47,39 +47,39 @@ type Client struct {
 //
 //nolint:revive
 type KubernetesClient interface {
-	GetPod(pod, namespace string) (*corev1.Pod, error)
-	GetDeployment(name, namespace string) (*appsv1.Deployment, error)
-	GetDaemonSet(name, namespace string) (*appsv1.DaemonSet, error)
-	GetStatefulSet(name, namespace string) (*appsv1.StatefulSet, error)
-	GetReplicaSet(name, namespace string) (*appsv1.ReplicaSet, error)
-	GetNode(name string) (*corev1.Node, error)
-	GetDeploymentFromPod(pod *corev1.Pod) (*appsv1.Deployment, error)
-	GetDaemonsetFromPod(pod *corev1.Pod) (*appsv1.DaemonSet, error)
-	GetStatefulsetFromPod(pod *corev1.Pod) (*appsv1.StatefulSet, error)
-	GetReplicasetFromPod(pod *corev1.Pod) (*appsv1.ReplicaSet, error)
-	GetNodeFromPod(pod *corev1.Pod) (*corev1.Node, error)
-	GetTarget(resource, name, namespace string) (any, error)
-	GetNamespace(name string) (*corev1.Namespace, error)
-	GetConfigMap(name, namespace string) (*corev1.ConfigMap, error)
-	GetSecret(name, namespace string) (*corev1.Secret, error)
-	GetService(name, namespace string) (*corev1.Service, error)
-	GetServiceAccount(name, namespace string) (*corev1.ServiceAccount, error)
-	GetRole(name, namespace string) (*rbacv1.Role, error)
-	GetClusterRole(name, namespace string) (*rbacv1.ClusterRole, error)
+	GetPod(ctx context.Context, pod, namespace string) (*corev1.Pod, error)
+	GetDeployment(ctx context.Context, name, namespace string) (*appsv1.Deployment, error)
+	GetDaemonSet(ctx context.Context, name, namespace string) (*appsv1.DaemonSet, error)
+	GetStatefulSet(ctx context.Context, name, namespace string) (*appsv1.StatefulSet, error)
+	GetReplicaSet(ctx context.Context, name, namespace string) (*appsv1.ReplicaSet, error)
+	GetNode(ctx context.Context, name string) (*corev1.Node, error)
+	GetDeploymentFromPod(ctx context.Context, pod *corev1.Pod) (*appsv1.Deployment, error)
+	GetDaemonsetFromPod(ctx context.Context, pod *corev1.Pod) (*appsv1.DaemonSet, error)
+	GetStatefulsetFromPod(ctx context.Context, pod *corev1.Pod) (*appsv1.StatefulSet, error)
+	GetReplicasetFromPod(ctx context.Context, pod *corev1.Pod) (*appsv1.ReplicaSet, error)
+	GetNodeFromPod(ctx context.Context, pod *corev1.Pod) (*corev1.Node, error)
+	GetTarget(ctx context.Context, resource, name, namespace string) (any, error)
+	GetNamespace(ctx context.Context, name string) (*corev1.Namespace, error)
+	GetConfigMap(ctx context.Context, name, namespace string) (*corev1.ConfigMap, error)
+	GetSecret(ctx context.Context, name, namespace string) (*corev1.Secret, error)
+	GetService(ctx context.Context, name, namespace string) (*corev1.Service, error)
+	GetServiceAccount(ctx context.Context, name, namespace string) (*corev1.ServiceAccount, error)
+	GetRole(ctx context.Context, name, namespace string) (*rbacv1.Role, error)
+	GetClusterRole(ctx context.Context, name, namespace string) (*rbacv1.ClusterRole, error)
 	GetWatcherEndpointSlices(labelSelector, namespace string) (<-chan watch.Event, error)
 	GetLeaseHolder() (<-chan string, error)
-	Exec(namespace, pod, container string, command []string, script string) (*bytes.Buffer, error)
-	CreateEphemeralContainer(pod *corev1.Pod, container, name string, ttl int) error
+	Exec(ctx context.Context, namespace, pod, container string, command []string, script string) (*bytes.Buffer, error)
+	CreateEphemeralContainer(ctx context.Context, pod *corev1.Pod, container, name, image string, ttl int) error
 	ListPods(ctx context.Context, opts metav1.ListOptions) (*corev1.PodList, error)
-	EvictPod(pod corev1.Pod) error
+	EvictPod(ctx context.Context, pod corev1.Pod) error
 }
 
 type DrainClient interface {
-	GetPod(name, namespace string) (*corev1.Pod, error)
-	GetNodeFromPod(pod *corev1.Pod) (*corev1.Node, error)
+	GetPod(ctx context.Context, name, namespace string) (*corev1.Pod, error)
+	GetNodeFromPod(ctx context.Context, pod *corev1.Pod) (*corev1.Node, error)
 	ListPods(ctx context.Context, options metav1.ListOptions) (*corev1.PodList, error)
-	EvictPod(pod corev1.Pod) error
-	GetReplicaSet(name, namespace string) (*appsv1.ReplicaSet, error)
+	EvictPod(ctx context.Context, pod corev1.Pod) error
+	GetReplicaSet(ctx context.Context, name, namespace string) (*appsv1.ReplicaSet, error)
 }
 
 var (
@@ -145,71 +145,77 @@ func GetClient() *Client {
 	return client
 }
 
-func (client Client) GetPod(pod, namespace string) (*corev1.Pod, error) {
-	p, err := client.Clientset.CoreV1().Pods(namespace).Get(context.Background(), pod, metav1.GetOptions{})
+func (client Client) GetPod(ctx context.Context, pod, namespace string) (*corev1.Pod, error) {
+	p, err := client.Clientset.CoreV1().Pods(namespace).Get(ctx, pod, metav1.GetOptions{})
 	if err != nil {
 		return nil, fmt.Errorf("the pod '%v' in the namespace '%v' doesn't exist", pod, namespace)
 	}
 	return p, nil
 }
 
-func (client Client) GetDeployment(name, namespace string) (*appsv1.Deployment, error) {
-	p, err := client.Clientset.AppsV1().Deployments(namespace).Get(context.Background(), name, metav1.GetOptions{})
+func (client Client) GetDeployment(ctx context.Context, name, namespace string) (*appsv1.Deployment, error) {
+	p, err := client.Clientset.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
 	if err != nil {
 		return nil, fmt.Errorf("the deployment '%v' in the namespace '%v' doesn't exist", name, namespace)
 	}
 	return p, nil
 }
 
-func (client Client) GetDaemonSet(name, namespace string) (*appsv1.DaemonSet, error) {
-	p, err := client.Clientset.AppsV1().DaemonSets(namespace).Get(context.Background(), name, metav1.GetOptions{})
+func (client Client) GetDaemonSet(ctx context.Context, name, namespace string) (*appsv1.DaemonSet, error) {
+	p, err := client.Clientset.AppsV1().DaemonSets(namespace).Get(ctx, name, metav1.GetOptions{})
 	if err != nil {
 		return nil, fmt.Errorf("the daemonset '%v' in the namespace '%v' doesn't exist", name, namespace)
 	}
 	return p, nil
 }
 
-func (client Client) GetStatefulSet(name, namespace string) (*appsv1.StatefulSet, error) {
-	p, err := client.Clientset.AppsV1().StatefulSets(namespace).Get(context.Background(), name, metav1.GetOptions{})
+func (client Client) GetStatefulSet(ctx context.Context, name, namespace string) (*appsv1.StatefulSet, error) {
+	p, err := client.Clientset.AppsV1().StatefulSets(namespace).Get(ctx, name, metav1.GetOptions{})
 	if err != nil {
 		return nil, fmt.Errorf("the statefulset '%v' in the namespace '%v' doesn't exist", name, namespace)
 	}
 	return p, nil
 }
 
-func (client Client) GetReplicaSet(name, namespace string) (*appsv1.ReplicaSet, error) {
-	p, err := client.Clientset.AppsV1().ReplicaSets(namespace).Get(context.Background(), name, metav1.GetOptions{})
+func (client Client) GetReplicaSet(ctx context.Context, name, namespace string) (*appsv1.ReplicaSet, error) {
+	p, err := client.Clientset.AppsV1().ReplicaSets(namespace).Get(ctx, name, metav1.GetOptions{})
 	if err != nil {
 		return nil, fmt.Errorf("the replicaset '%v' in the namespace '%v' doesn't exist", name, namespace)
 	}
 	return p, nil
 }
 
-func (client Client) GetNode(name string) (*corev1.Node, error) {
-	p, err := client.Clientset.CoreV1().Nodes().Get(context.Background(), name, metav1.GetOptions{})
+func (client Client) GetNode(ctx context.Context, name string) (*corev1.Node, error) {
+	p, err := client.Clientset.CoreV1().Nodes().Get(ctx, name, metav1.GetOptions{})
 	if err != nil {
 		return nil, fmt.Errorf("error getting node '%v': %v", name, err)
 	}
 	return p, nil
 }
 
-func (client Client) GetDeploymentFromPod(pod *corev1.Pod) (*appsv1.Deployment, error) {
-	podName := pod.OwnerReferences[0].Name
+func (client Client) GetDeploymentFromPod(ctx context.Context, pod *corev1.Pod) (*appsv1.Deployment, error) {
 	namespace := pod.ObjectMeta.Namespace
-	r, err := client.GetDeployment(podName, namespace)
+	rs, err := client.GetReplicasetFromPod(ctx, pod)
+	if err != nil {
+		return nil, err
+	}
+	if len(rs.OwnerReferences) == 0 || rs.OwnerReferences[0].Kind != "Deployment" {
+		return nil, fmt.Errorf("can't find the deployment for the pod'%v' in namespace '%v'", pod.ObjectMeta.Name, namespace)
+	}
+	r, err := client.GetDeployment(ctx, rs.OwnerReferences[0].Name, namespace)
 	if err != nil {
 		return nil, err
 	}
 	if r == nil {
-		return nil, fmt.Errorf("can't find the deployment for the pod'%v' in namespace '%v'", podName, namespace)
+		return nil, fmt.Errorf("can't find the deployment for the pod'%v' in namespace '%v'", pod.ObjectMeta.Name, namespace)
 	}
 	return r, nil
 }
 
-func (client Client) GetDaemonsetFromPod(pod *corev1.Pod) (*appsv1.DaemonSet, error) {
+func (client Client) GetDaemonsetFromPod(ctx context.Context, pod *corev1.Pod) (*appsv1.DaemonSet, error) {
 	podName := pod.OwnerReferences[0].Name
 	namespace := pod.ObjectMeta.Namespace
-	r, err := client.GetDaemonSet(podName, namespace)
+	r, err := client.GetDaemonSet(ctx, podName, namespace)
 	if err != nil {
 		return nil, err
 	}
@@ -219,10 +225,10 @@ func (client Client) GetDaemonsetFromPod(pod *corev1.Pod) (*appsv1.DaemonSet, er
 	return r, nil
 }
 
-func (client Client) GetStatefulsetFromPod(pod *corev1.Pod) (*appsv1.StatefulSet, error) {
+func (client Client) GetStatefulsetFromPod(ctx context.Context, pod *corev1.Pod) (*appsv1.StatefulSet, error) {
 	podName := pod.OwnerReferences[0].Name
 	namespace := pod.ObjectMeta.Namespace
-	r, err := client.GetStatefulSet(podName, namespace)
+	r, err := client.GetStatefulSet(ctx, podName, namespace)
 	if err != nil {
 		return nil, err
 	}
@@ -232,10 +238,10 @@ func (client Client) GetStatefulsetFromPod(pod *corev1.Pod) (*appsv1.StatefulSet
 	return r, nil
 }
 
-func (client Client) GetReplicasetFromPod(pod *corev1.Pod) (*appsv1.ReplicaSet, error) {
+func (client Client) GetReplicasetFromPod(ctx context.Context, pod *corev1.Pod) (*appsv1.ReplicaSet, error) {
 	podName := pod.OwnerReferences[0].Name
 	namespace := pod.ObjectMeta.Namespace
-	r, err := client.GetReplicaSet(podName, namespace)
+	r, err := client.GetReplicaSet(ctx, podName, namespace)
 	if err != nil {
 		return nil, err
 	}
@@ -245,11 +251,11 @@ func (client Client) GetReplicasetFromPod(pod *corev1.Pod) (*appsv1.ReplicaSet,
 	return r, nil
 }
 
-func (client Client) GetNodeFromPod(pod *corev1.Pod) (*corev1.Node, error) {
+func (client Client) GetNodeFromPod(ctx context.Context, pod *corev1.Pod) (*corev1.Node, error) {
 	podName := pod.GetName()
 	namespace := pod.GetNamespace()
 	nodeName := pod.Spec.NodeName
-	r, err := client.GetNode(nodeName)
+	r, err := client.GetNode(ctx, nodeName)
 	if err != nil {
 		return nil, err
 	}
@@ -259,85 +265,85 @@ func (client Client) GetNodeFromPod(pod *corev1.Pod) (*corev1.Node, error) {
 	return r, nil
 }
 
-func (client Client) GetTarget(resource, name, namespace string) (any, error) {
+func (client Client) GetTarget(ctx context.Context, resource, name, namespace string) (any, error) {
 	switch resource {
 	case "namespaces":
-		return client.GetNamespace(name)
+		return client.GetNamespace(ctx, name)
 	case "configmaps":
-		return client.GetConfigMap(name, namespace)
+		return client.GetConfigMap(ctx, name, namespace)
 	case "secrets":
-		return client.GetSecret(name, namespace)
+		return client.GetSecret(ctx, name, namespace)
 	case "deployments":
-		return client.GetDeployment(name, namespace)
+		return client.GetDeployment(ctx, name, namespace)
 	case "daemonsets":
-		return client.GetDeployment(name, namespace)
+		return client.GetDeployment(ctx, name, namespace)
 	case "statefulsets":
-		return client.GetStatefulSet(name, namespace)
+		return client.GetStatefulSet(ctx, name, namespace)
 	case "replicasets":
-		return client.GetReplicaSet(name, namespace)
+		return client.GetReplicaSet(ctx, name, namespace)
 	case "services":
-		return client.GetService(name, namespace)
+		return client.GetService(ctx, name, namespace)
 	case "serviceaccounts":
-		return client.GetServiceAccount(name, namespace)
+		return client.GetServiceAccount(ctx, name, namespace)
 	case "roles":
-		return client.GetRole(name, namespace)
+		return client.GetRole(ctx, name, namespace)
 	case "clusterroles":
-		return client.GetClusterRole(name, namespace)
+		return client.GetClusterRole(ctx, name, namespace)
 	}
 
 	return nil, errors.New("the resource doesn't exist or its type is not yet managed")
 }
 
-func (client Client) GetNamespace(name string) (*corev1.Namespace, error) {
-	p, err := client.Clientset.CoreV1().Namespaces().Get(context.Background(), name, metav1.GetOptions{})
+func (client Client) GetNamespace(ctx context.Context, name string) (*corev1.Namespace, error) {
+	p, err := client.Clientset.CoreV1().Namespaces().Get(ctx, name, metav1.GetOptions{})
 	if err != nil {
 		return nil, fmt.Errorf("the namespace '%v' doesn't exist", name)
 	}
 	return p, nil
 }
 
-func (client Client) GetConfigMap(name, namespace string) (*corev1.ConfigMap, error) {
-	p, err := client.Clientset.CoreV1().ConfigMaps(namespace).Get(context.Background(), name, metav1.GetOptions{})
+func (client Client) GetConfigMap(ctx context.Context, name, namespace string) (*corev1.ConfigMap, error) {
+	p, err := client.Clientset.CoreV1().ConfigMaps(namespace).Get(ctx, name, metav1.GetOptions{})
 	if err != nil {
 		return nil, fmt.Errorf("the configmap '%v' in the namespace '%v' doesn't exist", name, namespace)
 	}
 	return p, nil
 }
 
-func (client Client) GetSecret(name, namespace string) (*corev1.Secret, error) {
-	p, err := client.Clientset.CoreV1().Secrets(namespace).Get(context.Background(), name, metav1.GetOptions{})
+func (client Client) GetSecret(ctx context.Context, name, namespace string) (*corev1.Secret, error) {
+	p, err := client.Clientset.CoreV1().Secrets(namespace).Get(ctx, name, metav1.GetOptions{})
 	if err != nil {
 		return nil, fmt.Errorf("the secret '%v' in the namespace '%v' doesn't exist", name, namespace)
 	}
 	return p, nil
 }
 
-func (client Client) GetService(name, namespace string) (*corev1.Service, error) {
-	p, err := client.Clientset.CoreV1().Services(namespace).Get(context.Background(), name, metav1.GetOptions{})
+func (client Client) GetService(ctx context.Context, name, namespace string) (*corev1.Service, error) {
+	p, err := client.Clientset.CoreV1().Services(namespace).Get(ctx, name, metav1.GetOptions{})
 	if err != nil {
 		return nil, fmt.Errorf("the service '%v' in the namespace '%v' doesn't exist", name, namespace)
 	}
 	return p, nil
 }
 
-func (client Client) GetServiceAccount(name, namespace string) (*corev1.ServiceAccount, error) {
-	p, err := client.Clientset.CoreV1().ServiceAccounts(namespace).Get(context.Background(), name, metav1.GetOptions{})
+func (client Client) GetServiceAccount(ctx context.Context, name, namespace string) (*corev1.ServiceAccount, error) {
+	p, err := client.Clientset.CoreV1().ServiceAccounts(namespace).Get(ctx, name, metav1.GetOptions{})
 	if err != nil {
 		return nil, fmt.Errorf("the serviceaccount '%v' in the namespace '%v' doesn't exist", name, namespace)
 	}
 	return p, nil
 }
 
-func (client Client) GetRole(name, namespace string) (*rbacv1.Role, error) {
-	p, err := client.Clientset.RbacV1().Roles(namespace).Get(context.Background(), name, metav1.GetOptions{})
+func (client Client) GetRole(ctx context.Context, name, namespace string) (*rbacv1.Role, error) {
+	p, err := client.Clientset.RbacV1().Roles(namespace).Get(ctx, name, metav1.GetOptions{})
 	if err != nil {
 		return nil, fmt.Errorf("the role '%v' in the namespace '%v' doesn't exist", name, namespace)
 	}
 	return p, nil
 }
 
-func (client Client) GetClusterRole(name, namespace string) (*rbacv1.ClusterRole, error) {
-	p, err := client.Clientset.RbacV1().ClusterRoles().Get(context.Background(), name, metav1.GetOptions{})
+func (client Client) GetClusterRole(ctx context.Context, name, namespace string) (*rbacv1.ClusterRole, error) {
+	p, err := client.Clientset.RbacV1().ClusterRoles().Get(ctx, name, metav1.GetOptions{})
 	if err != nil {
 		return nil, fmt.Errorf("the clusterrole '%v' in the namespace '%v' doesn't exist", name, namespace)
 	}
@@ -357,20 +363,39 @@ func (client Client) GetWatcherEndpointSlices(labelSelector, namespace string) (
 	return watcher.ResultChan(), nil
 }
 
+// GetLeaseHolder runs the leader election with the Lease, it's created if it doesn't exist yet,
+// and returns a channel with the identity of each new leader
 func (client Client) GetLeaseHolder() (<-chan string, error) {
 	if leaseHolderChan != nil {
 		return leaseHolderChan, nil
 	}
 
-	leaseHolderChan = make(chan string, 20)
-	namespace := os.Getenv("NAMESPACE")
+	config := configuration.GetConfiguration().Deduplication
+	identity := config.Identity
+	if identity == "" {
+		identity = *utils.GetLocalIP()
+	}
+	if identity == "" {
+		return nil, errors.New("can't determine the identity for the leader election")
+	}
+	namespace := config.LeaseNamespace
+	if namespace == "" {
+		namespace = os.Getenv("NAMESPACE")
+	}
 	if namespace == "" {
 		namespace = "falco"
 	}
+	name := config.LeaseName
+	if name == "" {
+		name = "falco-talon"
+	}
+	leaseDuration := time.Duration(max(config.LeaseDurationSeconds, 2)) * time.Second
+
+	leaseHolderChan = make(chan string, 20)
 	leaderElectionConfig := leaderelection.LeaderElectionConfig{
 		Lock: &resourcelock.LeaseLock{
 			LeaseMeta: metav1.ObjectMeta{
-				Name:      "falco-talon",
+				Name:      name,
 				Namespace: namespace,
 				Labels: map[string]string{
 					"app.kubernetes.io/part-of": "falco-talon",
@@ -379,12 +404,12 @@ func (client Client) GetLeaseHolder() (<-chan string, error) {
 			},
 			Client: client.Clientset.CoordinationV1(),
 			LockConfig: resourcelock.ResourceLockConfig{
-				Identity: *utils.GetLocalIP(),
+				Identity: identity,
 			},
 		},
-		LeaseDuration: time.Duration(4) * time.Second,
-		RenewDeadline: time.Duration(3) * time.Second,
-		RetryPeriod:   time.Duration(2) * time.Second,
+		LeaseDuration: leaseDuration,
+		RenewDeadline: leaseDuration * 3 / 4,
+		RetryPeriod:   leaseDuration / 2,
 		Callbacks: leaderelection.LeaderCallbacks{
 			OnStartedLeading: func(_ context.Context) {},
 			OnStoppedLeading: func() {},
@@ -393,6 +418,7 @@ func (client Client) GetLeaseHolder() (<-chan string, error) {
 			},
 		},
 		ReleaseOnCancel: true,
+		Name:            name,
 	}
 
 	leaderElector, err := leaderelection.NewLeaderElector(leaderElectionConfig)
@@ -401,15 +427,17 @@ func (client Client) GetLeaseHolder() (<-chan string, error) {
 	}
 
 	go func() {
-		ctx, cancel := context.WithCancel(context.Background())
-		defer cancel()
-		leaderElector.Run(ctx)
+		// Run returns when the leadership is lost, the replica becomes a candidate again
+		for {
+			leaderElector.Run(context.Background())
+			time.Sleep(leaderElectionConfig.RetryPeriod)
+		}
 	}()
 
 	return leaseHolderChan, nil
 }
 
-func (client Client) Exec(namespace, pod, container string, command []string, script string) (*bytes.Buffer, error) {
+func (client Client) Exec(ctx context.Context, namespace, pod, container string, command []string, script string) (*bytes.Buffer, error) {
 	var err error
 	buf := &bytes.Buffer{}
 	errBuf := &bytes.Buffer{}
@@ -437,7 +465,7 @@ func (client Client) Exec(namespace, pod, container string, command []string, sc
 	if script != "" {
 		reader = strings.NewReader(script)
 	}
-	err = exec.StreamWithContext(context.Background(), remotecommand.StreamOptions{
+	err = exec.StreamWithContext(ctx, remotecommand.StreamOptions{
 		Stdin:  reader,
 		Stdout: buf,
 		Stderr: errBuf,
@@ -451,7 +479,7 @@ func (client Client) Exec(namespace, pod, container string, command []string, sc
 	return buf, nil
 }
 
-func (client Client) CreateEphemeralContainer(pod *corev1.Pod, container, name, image string, ttl int) error {
+func (client Client) CreateEphemeralContainer(ctx context.Context, pod *corev1.Pod, container, name, image string, ttl int) error {
 	ec := &corev1.EphemeralContainer{
 		EphemeralContainerCommon: corev1.EphemeralContainerCommon{
 			Name:                     name,
@@ -486,7 +514,7 @@ func (client Client) CreateEphemeralContainer(pod *corev1.Pod, container, name,
 	_, err = client.CoreV1().
 		Pods(pod.Namespace).
 		Patch(
-			context.Background(),
+			ctx,
 			pod.Name,
 			types.StrategicMergePatchType,
 			patch,
@@ -505,10 +533,12 @@ func (client Client) CreateEphemeralContainer(pod *corev1.Pod, container, name,
 	var ready bool
 	for !ready {
 		select {
+		case <-ctx.Done():
+			return ctx.Err()
 		case <-timeout.C:
 			return fmt.Errorf("ephemeral container for the tcpdump not ready in the pod '%v' in the namespace '%v'", pod.Name, pod.Namespace)
 		case <-ticker.C:
-			p, err := client.GetPod(pod.Name, pod.Namespace)
+			p, err := client.GetPod(ctx, pod.Name, pod.Namespace)
 			if err != nil {
 				return err
 			}
@@ -527,14 +557,14 @@ func (client Client) ListPods(ctx context.Context, opts metav1.ListOptions) (*co
 	return client.CoreV1().Pods("").List(ctx, opts)
 }
 
-func (client Client) EvictPod(pod corev1.Pod) error {
+func (client Client) EvictPod(ctx context.Context, pod corev1.Pod) error {
 	eviction := &policyv1.Eviction{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      pod.Name,
 			Namespace: pod.Namespace,
 		},
 	}
-	err := client.PolicyV1().Evictions(pod.GetNamespace()).Evict(context.Background(), eviction)
+	err := client.PolicyV1().Evictions(pod.GetNamespace()).Evict(ctx, eviction)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,106 @@
+package leaderelection
+
+import (
+	"net"
+	"slices"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/falcosecurity/falco-talon/configuration"
+	k8s "github.com/falcosecurity/falco-talon/internal/kubernetes/client"
+	"github.com/falcosecurity/falco-talon/utils"
+)
+
+const (
+	dialTimeout = 1 * time.Second
+)
+
+var (
+	// enabled is true once the election is started, without election the replica is considered as the leader
+	enabled atomic.Bool
+	elected atomic.Bool
+)
+
+// Start starts the leader election and returns a channel with the identity of each new leader, the election
+// uses the static list of peers if it's set, a Lease in Kubernetes otherwise
+func Start() (<-chan string, error) {
+	config := configuration.GetConfiguration().Deduplication
+	var c <-chan string
+	if len(config.Peers) != 0 {
+		period := time.Duration(max(config.LeaseDurationSeconds, 2)) * time.Second / 2
+		// the peers are probed on the port of Falco Talon, the NATS may be external and shared by all of them
+		c = startStatic(GetIdentity(), config.Peers, configuration.GetConfiguration().ListenPort, period)
+	} else {
+		if err := k8s.Init(); err != nil {
+			return nil, err
+		}
+		var err error
+		c, err = k8s.GetClient().GetLeaseHolder()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	enabled.Store(true)
+	leaders := make(chan string, 20)
+	go func() {
+		identity := GetIdentity()
+		for i := range c {
+			elected.Store(i == identity)
+			leaders <- i
+		}
+	}()
+	return leaders, nil
+}
+
+// IsLeader returns true if the replica is the current leader, or if the election isn't started
+func IsLeader() bool {
+	return !enabled.Load() || elected.Load()
+}
+
+// GetIdentity returns the identity of the replica in the election
+func GetIdentity() string {
+	if identity := configuration.GetConfiguration().Deduplication.Identity; identity != "" {
+		return identity
+	}
+	return *utils.GetLocalIP()
+}
+
+// startStatic elects as leader the first peer, in alphabetical order, with a reachable Falco Talon on the port,
+// the election is repeated at each period to follow the failures and the recoveries of the peers
+func startStatic(identity string, peers []string, port int, period time.Duration) <-chan string {
+	candidates := utils.Deduplicate(append(slices.Clone(peers), identity))
+	slices.Sort(candidates)
+	if !slices.Contains(peers, identity) {
+		utils.PrintLog("warning", utils.LogLine{Error: "the identity '" + identity + "' is not in the list of peers", Message: "leader-election"})
+	}
+
+	c := make(chan string, 20)
+	go func() {
+		var leader string
+		for {
+			if l := elect(identity, candidates, port); l != leader {
+				leader = l
+				c <- leader
+			}
+			time.Sleep(period)
+		}
+	}()
+	return c
+}
+
+func elect(identity string, candidates []string, port int) string {
+	for _, i := range candidates {
+		if i == identity {
+			return i
+		}
+		conn, err := net.DialTimeout("tcp", net.JoinHostPort(i, strconv.Itoa(port)), dialTimeout)
+		if err != nil {
+			continue
+		}
+		conn.Close()
+		return i
+	}
+	return identity
+}
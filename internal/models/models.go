@@ -19,6 +19,8 @@ type Data struct {
 	Name    string
 	Objects map[string]string
 	Bytes   []byte
+	// Rollback is the state saved by the actionner to revert its changes
+	Rollback []byte
 }
 
 type Parameters any
@@ -2,7 +2,9 @@ package nats
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
+	"strings"
 	"time"
 
 	"go.opentelemetry.io/otel"
@@ -12,6 +14,8 @@ import (
 
 	natsserver "github.com/nats-io/nats-server/v2/server"
 	nats "github.com/nats-io/nats.go"
+
+	"github.com/falcosecurity/falco-talon/configuration"
 )
 
 type Client struct {
@@ -19,23 +23,38 @@ type Client struct {
 }
 
 const (
-	streamName     = "EVENTS"
-	streamSubjects = "EVENTS.*"
+	defaultStreamName = "EVENTS"
+	fileStr           = "file"
+	// fileMaxAge is the default retention of the events with the file storage, to keep them across the restarts
+	fileMaxAge = 24 * time.Hour
 )
 
 type MessageWithContext struct {
 	Ctx  context.Context
+	msg  *nats.Msg
 	Data []byte
 }
 
 var consumer, publisher *Client
 
+var (
+	streamName     = defaultStreamName
+	streamSubjects = defaultStreamName + ".*"
+)
+
+// StartServer starts the embedded NATS server and connects the consumer and the publisher to it
 func StartServer(timeWindow int) (*natsserver.Server, error) {
-	ns, err := natsserver.NewServer(
-		&natsserver.Options{
-			JetStream: true,
-			// StoreDir:  nats.MemoryStorage.String(),
-		})
+	config := configuration.GetConfiguration()
+	if config.NATS.StoreDir == "" {
+		return nil, fmt.Errorf("the 'nats.store_dir' setting is required, the pending approvals would be lost at the restarts")
+	}
+	// the store dir is always set, the approvals, the rollbacks and the dead letters are kept in a file
+	// storage whatever the storage of the events, they must survive the restarts
+	options := &natsserver.Options{
+		JetStream: true,
+		StoreDir:  config.NATS.StoreDir,
+	}
+	ns, err := natsserver.NewServer(options)
 	if err != nil {
 		return nil, err
 	}
@@ -45,25 +64,88 @@ func StartServer(timeWindow int) (*natsserver.Server, error) {
 		return nil, fmt.Errorf("connection timeout")
 	}
 
+	if err := connect(timeWindow, nats.DefaultURL); err != nil {
+		return nil, err
+	}
+
+	return ns, nil
+}
+
+// Connect connects the consumer and the publisher to the external NATS cluster set with 'nats.url',
+// the replicas of Falco Talon share its stream and its consumer
+func Connect(timeWindow int) error {
+	config := configuration.GetConfiguration()
+	opts, err := GetOptions()
+	if err != nil {
+		return err
+	}
+	return connect(timeWindow, config.NATS.URL, opts...)
+}
+
+func connect(timeWindow int, addr string, opts ...nats.Option) error {
+	config := configuration.GetConfiguration()
+	if config.NATS.StreamName != "" {
+		streamName = config.NATS.StreamName
+		streamSubjects = streamName + ".*"
+	}
+
 	consumer = new(Client)
 	publisher = new(Client)
 
-	if err := consumer.SetJetStreamContext(nats.DefaultURL); err != nil {
-		return nil, err
+	if err := consumer.SetJetStreamContext(addr, opts...); err != nil {
+		return err
 	}
-	if err := publisher.SetJetStreamContext(nats.DefaultURL); err != nil {
-		return nil, err
+	if err := publisher.SetJetStreamContext(addr, opts...); err != nil {
+		return err
 	}
 
-	if err := consumer.createStream(timeWindow); err != nil {
-		return nil, err
+	return consumer.createStream(timeWindow)
+}
+
+// GetOptions returns the options for the authentication and the TLS with the external NATS cluster
+func GetOptions() ([]nats.Option, error) {
+	config := configuration.GetConfiguration().NATS
+	opts := []nats.Option{nats.Name("falco-talon")}
+
+	switch {
+	case config.CredsFile != "":
+		opts = append(opts, nats.UserCredentials(config.CredsFile))
+	case config.JWT != "":
+		if config.NKeySeed == "" {
+			return nil, fmt.Errorf("the nkey seed is required with the jwt")
+		}
+		opts = append(opts, nats.UserJWTAndSeed(config.JWT, config.NKeySeed))
+	case config.NKeySeedFile != "":
+		o, err := nats.NkeyOptionFromSeed(config.NKeySeedFile)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, o)
+	case config.Token != "":
+		opts = append(opts, nats.Token(config.Token))
+	case config.User != "":
+		opts = append(opts, nats.UserInfo(config.User, config.Password))
 	}
 
-	return ns, nil
+	if config.TLS.InsecureSkipVerify {
+		// #nosec G402 this is an explicit choice of the user
+		opts = append(opts, nats.Secure(&tls.Config{InsecureSkipVerify: true}))
+	}
+	if config.TLS.CAFile != "" {
+		opts = append(opts, nats.RootCAs(config.TLS.CAFile))
+	}
+	if config.TLS.CertFile != "" || config.TLS.KeyFile != "" {
+		if config.TLS.CertFile == "" || config.TLS.KeyFile == "" {
+			return nil, fmt.Errorf("both the cert and the key files are required for the mTLS")
+		}
+		opts = append(opts, nats.ClientCert(config.TLS.CertFile, config.TLS.KeyFile))
+	}
+
+	return opts, nil
 }
 
-func (client *Client) SetJetStreamContext(addr string) error {
-	nc, err := nats.Connect(addr)
+func (client *Client) SetJetStreamContext(addr string, opts ...nats.Option) error {
+	nc, err := nats.Connect(addr, opts...)
 	if err != nil {
 		return err
 	}
@@ -85,18 +167,49 @@ func GetPublisher() *Client {
 	return publisher
 }
 
+// ConsumeMsg subscribes to the events with a durable consumer, the events are acknowledged at the reception
+// or once processed, according to the 'nats.ack_after_processing' setting
 func (client *Client) ConsumeMsg() (chan MessageWithContext, error) {
+	config := configuration.GetConfiguration()
 	c := make(chan MessageWithContext, 20)
-	_, err := client.JetStreamContext.Subscribe(streamSubjects, func(m *nats.Msg) {
+	opts := []nats.SubOpt{
+		nats.DeliverNew(),
+		nats.ManualAck(),
+		nats.AckExplicit(),
+	}
+	if config.NATS.DurableName != "" {
+		opts = append(opts, nats.Durable(config.NATS.DurableName))
+	}
+	if config.NATS.AckWaitSeconds > 0 {
+		opts = append(opts, nats.AckWait(time.Duration(config.NATS.AckWaitSeconds)*time.Second))
+	}
+	if config.NATS.MaxDeliver > 0 {
+		opts = append(opts, nats.MaxDeliver(config.NATS.MaxDeliver))
+	}
+	if config.NATS.MaxInFlight > 0 {
+		opts = append(opts, nats.MaxAckPending(config.NATS.MaxInFlight))
+	}
+	handler := func(m *nats.Msg) {
 		propagator := otel.GetTextMapPropagator()
 		ctx := propagator.Extract(context.Background(), propagation.HeaderCarrier(m.Header))
 
-		if err := m.Ack(); err != nil {
+		if !config.NATS.AckAfterProcessing {
+			if err := m.Ack(); err != nil {
+				return
+			}
+			c <- MessageWithContext{Data: m.Data, Ctx: ctx}
 			return
 		}
-		c <- MessageWithContext{Data: m.Data, Ctx: ctx}
-	},
-		nats.DeliverNew())
+		c <- MessageWithContext{Data: m.Data, Ctx: ctx, msg: m}
+	}
+
+	var err error
+	if config.NATS.DurableName != "" {
+		// the replicas connected to the same cluster share the events through the queue group
+		_, err = client.JetStreamContext.QueueSubscribe(streamSubjects, config.NATS.DurableName, handler, opts...)
+	} else {
+		_, err = client.JetStreamContext.Subscribe(streamSubjects, handler, opts...)
+	}
 
 	if err != nil {
 		return nil, err
@@ -105,6 +218,30 @@ func (client *Client) ConsumeMsg() (chan MessageWithContext, error) {
 	return c, nil
 }
 
+// Ack acknowledges a processed message, it does nothing if the message has been acknowledged at the reception
+func (m MessageWithContext) Ack() error {
+	if m.msg == nil {
+		return nil
+	}
+	return m.msg.Ack()
+}
+
+// Term stops the deliveries of a message which can't be processed
+func (m MessageWithContext) Term() error {
+	if m.msg == nil {
+		return nil
+	}
+	return m.msg.Term()
+}
+
+// InProgress resets the delay before a new delivery of a message still being processed
+func (m MessageWithContext) InProgress() error {
+	if m.msg == nil {
+		return nil
+	}
+	return m.msg.InProgress()
+}
+
 func (client *Client) PublishMsg(ctx context.Context, id, msg string) error {
 	natsMsg := &nats.Msg{
 		Subject: streamName + "." + id,
@@ -125,6 +262,27 @@ func (client *Client) PublishMsg(ctx context.Context, id, msg string) error {
 }
 
 func (client *Client) createStream(timeWindow int) error {
+	config := configuration.GetConfiguration()
+	// the retention is distinct from the deduplication time window, it can't be shorter though
+	maxAge := time.Duration(timeWindow) * time.Second
+	storage := nats.MemoryStorage
+	if strings.ToLower(config.NATS.Storage) == fileStr {
+		storage = nats.FileStorage
+		maxAge = max(maxAge, fileMaxAge)
+	}
+	if config.NATS.MaxAgeSeconds > 0 {
+		maxAge = max(time.Duration(timeWindow)*time.Second, time.Duration(config.NATS.MaxAgeSeconds)*time.Second)
+	}
+	streamConfig := &nats.StreamConfig{
+		Name:              streamName,
+		Subjects:          []string{streamSubjects},
+		Duplicates:        time.Duration(timeWindow) * time.Second,
+		MaxAge:            maxAge,
+		MaxMsgsPerSubject: 1,
+		Storage:           storage,
+		Replicas:          max(config.NATS.StreamReplicas, 1),
+	}
+
 	stream, err := client.JetStreamContext.StreamInfo(streamName)
 	if err != nil {
 		if err != nats.ErrStreamNotFound {
@@ -132,17 +290,52 @@ func (client *Client) createStream(timeWindow int) error {
 		}
 	}
 	if stream == nil {
-		_, err = client.JetStreamContext.AddStream(&nats.StreamConfig{
-			Name:              streamName,
-			Subjects:          []string{streamSubjects},
-			Duplicates:        time.Duration(timeWindow) * time.Second,
-			MaxAge:            time.Duration(timeWindow) * time.Second,
-			MaxMsgsPerSubject: 1,
-			Storage:           nats.MemoryStorage,
-		})
-		if err != nil {
-			return err
-		}
+		_, err = client.JetStreamContext.AddStream(streamConfig)
+		return err
 	}
-	return nil
+	if stream.Config.Storage != storage {
+		return fmt.Errorf("the stream '%v' already exists with a %v storage", streamName, stream.Config.Storage)
+	}
+	_, err = client.JetStreamContext.UpdateStream(streamConfig)
+	return err
+}
+
+// GetKeyValue returns the key-value store with the given name, it's created if it doesn't exist yet
+func (client *Client) GetKeyValue(bucket string) (nats.KeyValue, error) {
+	return client.GetKeyValueWithTTL(bucket, 0)
+}
+
+// GetKeyValueWithTTL returns the key-value store with the given name, it's created with the ttl for its
+// entries if it doesn't exist yet
+func (client *Client) GetKeyValueWithTTL(bucket string, ttl time.Duration) (nats.KeyValue, error) {
+	kv, err := client.JetStreamContext.KeyValue(bucket)
+	if err == nil {
+		return kv, nil
+	}
+	if err != nats.ErrBucketNotFound {
+		return nil, err
+	}
+	return client.JetStreamContext.CreateKeyValue(&nats.KeyValueConfig{
+		Bucket:  bucket,
+		TTL:     ttl,
+		Storage: nats.FileStorage,
+	})
+}
+
+// GetStream returns the information of the stream with the given name, it's created with a file storage
+// if it doesn't exist yet
+func (client *Client) GetStream(name string, maxAge time.Duration) (*nats.StreamInfo, error) {
+	stream, err := client.JetStreamContext.StreamInfo(name)
+	if err == nil {
+		return stream, nil
+	}
+	if err != nats.ErrStreamNotFound {
+		return nil, err
+	}
+	return client.JetStreamContext.AddStream(&nats.StreamConfig{
+		Name:     name,
+		Subjects: []string{name + ".*"},
+		MaxAge:   maxAge,
+		Storage:  nats.FileStorage,
+	})
 }
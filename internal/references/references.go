@@ -0,0 +1,57 @@
+package references
+
+import (
+	"encoding/json"
+	"slices"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Annotation contains, for each entry added to an object by Falco Talon (a CIDR, an IP, a rule), the trace ids of
+// the events whose actions rely on it; a rollback removes an entry only when no other event relies on it
+const Annotation string = "falco-talon.falcosecurity.org/references"
+
+// Get returns the references of the entries of an object
+func Get(annotations map[string]string) map[string][]string {
+	refs := make(map[string][]string)
+	if v, ok := annotations[Annotation]; ok {
+		_ = json.Unmarshal([]byte(v), &refs)
+	}
+	return refs
+}
+
+// Set sets the annotation with the references of the entries of an object
+func Set(meta *metav1.ObjectMeta, refs map[string][]string) {
+	if len(refs) == 0 {
+		delete(meta.Annotations, Annotation)
+		return
+	}
+	if meta.Annotations == nil {
+		meta.Annotations = make(map[string]string)
+	}
+	b, _ := json.Marshal(refs)
+	meta.Annotations[Annotation] = string(b)
+}
+
+// Add records that the event relies on the entry
+func Add(refs map[string][]string, entry, traceID string) {
+	if !slices.Contains(refs[entry], traceID) {
+		refs[entry] = append(refs[entry], traceID)
+	}
+}
+
+// Release removes the reference of the event to the entry, it returns true if the entry is tracked and no other
+// event relies on it anymore; the entry is then forgotten
+func Release(refs map[string][]string, entry, traceID string) bool {
+	v, ok := refs[entry]
+	if !ok {
+		return false
+	}
+	v = slices.DeleteFunc(v, func(i string) bool { return i == traceID })
+	if len(v) != 0 {
+		refs[entry] = v
+		return false
+	}
+	delete(refs, entry)
+	return true
+}
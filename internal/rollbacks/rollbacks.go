@@ -0,0 +1,145 @@
+package rollbacks
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	natsgo "github.com/nats-io/nats.go"
+
+	"github.com/falcosecurity/falco-talon/internal/nats"
+	"github.com/falcosecurity/falco-talon/utils"
+)
+
+// Change is the record of the modifications done by an action
+type Change struct {
+	Time      time.Time `json:"time"`
+	TraceID   string    `json:"trace_id"`
+	Rule      string    `json:"rule"`
+	Action    string    `json:"action"`
+	Actionner string    `json:"actionner"`
+	State     []byte    `json:"state"`
+}
+
+// Status is the progress of the rollback of the changes for a trace id
+type Status struct {
+	StartedAt time.Time       `json:"started_at"`
+	EndedAt   *time.Time      `json:"ended_at,omitempty"`
+	TraceID   string          `json:"trace_id"`
+	Status    string          `json:"status"`
+	Error     string          `json:"error,omitempty"`
+	Results   []utils.LogLine `json:"results,omitempty"`
+}
+
+const (
+	bucketName       string        = "ROLLBACKS"
+	statusBucketName string        = "ROLLBACKS_STATUS"
+	statusTTL        time.Duration = 24 * time.Hour
+	maxAttempts      int           = 5
+)
+
+var (
+	ErrNotFound       = errors.New("no change found for this trace id")
+	ErrStatusNotFound = errors.New("no rollback found for this trace id")
+)
+
+func getBucket() (natsgo.KeyValue, error) {
+	return nats.GetPublisher().GetKeyValue(bucketName)
+}
+
+// Add records the changes for their trace id
+func Add(changes ...*Change) error {
+	if len(changes) == 0 {
+		return nil
+	}
+	kv, err := getBucket()
+	if err != nil {
+		return err
+	}
+	traceID := changes[0].TraceID
+	for n := 0; n < maxAttempts; n++ {
+		var list []*Change
+		var revision uint64
+		entry, err := kv.Get(traceID)
+		switch {
+		case err == nil:
+			if err := json.Unmarshal(entry.Value(), &list); err != nil {
+				return err
+			}
+			revision = entry.Revision()
+		case !errors.Is(err, natsgo.ErrKeyNotFound):
+			return err
+		}
+		list = append(list, changes...)
+		b, err := json.Marshal(list)
+		if err != nil {
+			return err
+		}
+		if revision == 0 {
+			_, err = kv.Create(traceID, b)
+		} else {
+			_, err = kv.Update(traceID, b, revision)
+		}
+		if err == nil {
+			return nil
+		}
+	}
+	return errors.New("too many concurrent updates of the changes")
+}
+
+// Take removes the changes of a trace id from the store and returns them, from the oldest to the newest
+func Take(traceID string) ([]*Change, error) {
+	kv, err := getBucket()
+	if err != nil {
+		return nil, err
+	}
+	entry, err := kv.Get(traceID)
+	if err != nil {
+		if errors.Is(err, natsgo.ErrKeyNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	var list []*Change
+	if err := json.Unmarshal(entry.Value(), &list); err != nil {
+		return nil, err
+	}
+	if err := kv.Delete(traceID, natsgo.LastRevision(entry.Revision())); err != nil {
+		return nil, ErrNotFound
+	}
+	return list, nil
+}
+
+// SetStatus records the progress of a rollback, it's kept for 24 hours
+func SetStatus(status *Status) error {
+	kv, err := nats.GetPublisher().GetKeyValueWithTTL(statusBucketName, statusTTL)
+	if err != nil {
+		return err
+	}
+	b, err := json.Marshal(status)
+	if err != nil {
+		return err
+	}
+	_, err = kv.Put(status.TraceID, b)
+	return err
+}
+
+// GetStatus returns the progress of the last rollback for a trace id
+func GetStatus(traceID string) (*Status, error) {
+	kv, err := nats.GetPublisher().GetKeyValueWithTTL(statusBucketName, statusTTL)
+	if err != nil {
+		return nil, err
+	}
+	entry, err := kv.Get(traceID)
+	if err != nil {
+		if errors.Is(err, natsgo.ErrKeyNotFound) {
+			return nil, ErrStatusNotFound
+		}
+		return nil, err
+	}
+	var status Status
+	if err := json.Unmarshal(entry.Value(), &status); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
@@ -0,0 +1,372 @@
+package rules
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/falcosecurity/falco-talon/internal/events"
+)
+
+// condition is a node of a compiled 'match.condition' expression
+type condition interface {
+	eval(event *events.Event) bool
+}
+
+type andCondition struct {
+	left, right condition
+}
+
+type orCondition struct {
+	left, right condition
+}
+
+type notCondition struct {
+	cond condition
+}
+
+type existsCondition struct {
+	field string
+}
+
+type compareCondition struct {
+	field string
+	comp  outputfield
+}
+
+const (
+	andStr    string = "and"
+	orStr     string = "or"
+	notStr    string = "not"
+	existsStr string = "exists"
+
+	ruleFieldStr         string = "rule"
+	priorityFieldStr     string = "priority"
+	sourceFieldStr       string = "source"
+	hostnameFieldStr     string = "hostname"
+	tagsFieldStr         string = "tags"
+	outputFieldsFieldStr string = "output_fields"
+)
+
+type token struct {
+	value  string
+	quoted bool
+}
+
+type conditionParser struct {
+	tokens []token
+	pos    int
+}
+
+// parseCondition compiles the expression of a 'match.condition', the syntax is:
+//
+//	expr       := term ( "or" term )*
+//	term       := factor ( "and" factor )*
+//	factor     := "not" factor | "(" expr ")" | comparison
+//	comparison := field "exists" | field operator value | field "in" "(" value ( "," value )* ")"
+//	field      := rule | priority | source | hostname | tags | output_fields[<key>] | output_fields.<key>
+func parseCondition(s string) (condition, error) {
+	tokens, err := tokenizeCondition(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, nil
+	}
+	p := &conditionParser{tokens: tokens}
+	c, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("unexpected '%v' in the condition", p.tokens[p.pos].value)
+	}
+	return c, nil
+}
+
+func tokenizeCondition(s string) ([]token, error) {
+	var tokens []token
+	r := []rune(s)
+	for i := 0; i < len(r); {
+		c := r[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '(' || c == ')' || c == ',':
+			tokens = append(tokens, token{value: string(c)})
+			i++
+		case c == '"':
+			j := i + 1
+			for j < len(r) && r[j] != '"' {
+				j++
+			}
+			if j == len(r) {
+				return nil, fmt.Errorf("unterminated string in the condition")
+			}
+			tokens = append(tokens, token{value: string(r[i+1 : j]), quoted: true})
+			i = j + 1
+		case strings.ContainsRune("=!<>~", c):
+			j := i + 1
+			if j < len(r) && strings.ContainsRune("=~", r[j]) {
+				j++
+			}
+			op := string(r[i:j])
+			switch op {
+			case equalStr, notEqualStr, regexStr, notRegexStr, lowerStr, lowerEqStr, greaterStr, greaterEqStr:
+			default:
+				return nil, fmt.Errorf("unknown operator '%v' in the condition", op)
+			}
+			tokens = append(tokens, token{value: op})
+			i = j
+		default:
+			j := i
+			for j < len(r) && !strings.ContainsRune(" \t\n\r(),\"=!<>~", r[j]) {
+				j++
+			}
+			tokens = append(tokens, token{value: string(r[i:j])})
+			i = j
+		}
+	}
+	return tokens, nil
+}
+
+func (p *conditionParser) peek() (token, bool) {
+	if p.pos >= len(p.tokens) {
+		return token{}, false
+	}
+	return p.tokens[p.pos], true
+}
+
+func (p *conditionParser) next() (token, error) {
+	t, ok := p.peek()
+	if !ok {
+		return t, fmt.Errorf("unexpected end of the condition")
+	}
+	p.pos++
+	return t, nil
+}
+
+func (p *conditionParser) isKeyword(keyword string) bool {
+	t, ok := p.peek()
+	return ok && !t.quoted && strings.EqualFold(t.value, keyword)
+}
+
+func (p *conditionParser) parseOr() (condition, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.isKeyword(orStr) {
+		p.pos++
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &orCondition{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *conditionParser) parseAnd() (condition, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.isKeyword(andStr) {
+		p.pos++
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = &andCondition{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *conditionParser) parseNot() (condition, error) {
+	if p.isKeyword(notStr) {
+		p.pos++
+		c, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return &notCondition{cond: c}, nil
+	}
+	if t, ok := p.peek(); ok && !t.quoted && t.value == "(" {
+		p.pos++
+		c, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		t, err := p.next()
+		if err != nil || t.quoted || t.value != ")" {
+			return nil, fmt.Errorf("missing ')' in the condition")
+		}
+		return c, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *conditionParser) parseComparison() (condition, error) {
+	t, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+	field := t.value
+	if t.quoted || !isValidConditionField(field) {
+		return nil, fmt.Errorf("unknown field '%v' in the condition", field)
+	}
+
+	op, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+	if op.quoted {
+		return nil, fmt.Errorf("missing operator after '%v' in the condition", field)
+	}
+
+	comparator := strings.ToLower(op.value)
+	switch comparator {
+	case existsStr:
+		return &existsCondition{field: field}, nil
+	case inStr:
+		if t, err2 := p.next(); err2 != nil || t.quoted || t.value != "(" {
+			return nil, fmt.Errorf("missing '(' after 'in' in the condition")
+		}
+		f := outputfield{Key: field, Comparator: inStr}
+		for {
+			v, err2 := p.next()
+			if err2 != nil {
+				return nil, fmt.Errorf("missing ')' in the condition")
+			}
+			if !v.quoted && v.value == ")" && len(f.values) == 0 {
+				return nil, fmt.Errorf("empty list for '%v' in the condition", field)
+			}
+			if !v.quoted && (v.value == "(" || v.value == ")" || v.value == ",") {
+				return nil, fmt.Errorf("unexpected '%v' in the list for '%v' in the condition", v.value, field)
+			}
+			f.values = append(f.values, v.value)
+			s, err2 := p.next()
+			if err2 != nil {
+				return nil, fmt.Errorf("missing ')' in the condition")
+			}
+			if !s.quoted && s.value == ")" {
+				break
+			}
+			if s.quoted || s.value != "," {
+				return nil, fmt.Errorf("unexpected '%v' in the list for '%v' in the condition", s.value, field)
+			}
+		}
+		return &compareCondition{field: field, comp: f}, nil
+	case equalStr, notEqualStr, regexStr, notRegexStr, lowerStr, lowerEqStr, greaterStr, greaterEqStr, startswithStr, endswithStr, containsStr:
+	default:
+		return nil, fmt.Errorf("unknown operator '%v' in the condition", op.value)
+	}
+
+	v, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+	if !v.quoted && (v.value == "(" || v.value == ")" || v.value == ",") {
+		return nil, fmt.Errorf("missing value for '%v' in the condition", field)
+	}
+
+	value := v.value
+	if field == priorityFieldStr && isNumericComparator(comparator) {
+		n := getPriorityNumber(value)
+		if n == Default {
+			return nil, fmt.Errorf("incorrect priority '%v' in the condition", value)
+		}
+		value = strconv.Itoa(n)
+	}
+	if v.quoted && (comparator == equalStr || comparator == notEqualStr) && strings.Contains(value, "*") {
+		// a quoted value is never a glob
+		return &compareCondition{field: field, comp: outputfield{Key: field, Comparator: comparator, Value: value}}, nil
+	}
+	f, err := newOutputField(field, comparator, value)
+	if err != nil {
+		return nil, err
+	}
+	return &compareCondition{field: field, comp: f}, nil
+}
+
+func isNumericComparator(comparator string) bool {
+	switch comparator {
+	case lowerStr, lowerEqStr, greaterStr, greaterEqStr:
+		return true
+	}
+	return false
+}
+
+func isValidConditionField(field string) bool {
+	switch field {
+	case ruleFieldStr, priorityFieldStr, sourceFieldStr, hostnameFieldStr, tagsFieldStr:
+		return true
+	}
+	return getOutputFieldKey(field) != ""
+}
+
+// getOutputFieldKey returns the key of an output field referenced as output_fields[key] or output_fields.key
+func getOutputFieldKey(field string) string {
+	if strings.HasPrefix(field, outputFieldsFieldStr+"[") && strings.HasSuffix(field, "]") {
+		return strings.TrimSuffix(strings.TrimPrefix(field, outputFieldsFieldStr+"["), "]")
+	}
+	if strings.HasPrefix(field, outputFieldsFieldStr+".") {
+		return strings.TrimPrefix(field, outputFieldsFieldStr+".")
+	}
+	return ""
+}
+
+func getConditionFieldValues(field string, event *events.Event, numeric bool) []any {
+	switch field {
+	case ruleFieldStr:
+		return []any{event.Rule}
+	case priorityFieldStr:
+		if numeric {
+			return []any{getPriorityNumber(event.Priority)}
+		}
+		return []any{event.Priority}
+	case sourceFieldStr:
+		return []any{event.Source}
+	case hostnameFieldStr:
+		return []any{event.Hostname}
+	case tagsFieldStr:
+		return event.Tags
+	}
+	if v, ok := event.OutputFields[getOutputFieldKey(field)]; ok && v != nil {
+		return []any{v}
+	}
+	return nil
+}
+
+func (c *andCondition) eval(event *events.Event) bool {
+	return c.left.eval(event) && c.right.eval(event)
+}
+
+func (c *orCondition) eval(event *events.Event) bool {
+	return c.left.eval(event) || c.right.eval(event)
+}
+
+func (c *notCondition) eval(event *events.Event) bool {
+	return !c.cond.eval(event)
+}
+
+func (c *existsCondition) eval(event *events.Event) bool {
+	return len(getConditionFieldValues(c.field, event, false)) != 0
+}
+
+// eval returns true if at least one value of the field matches, for the negative
+// comparators ('!=' and '!~') it returns true if none of them matches
+func (c *compareCondition) eval(event *events.Event) bool {
+	var match bool
+	for _, i := range getConditionFieldValues(c.field, event, isNumericComparator(c.comp.Comparator)) {
+		if c.comp.match(i) {
+			match = true
+			break
+		}
+	}
+	if c.comp.isNegative() {
+		return !match
+	}
+	return match
+}
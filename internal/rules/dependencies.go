@@ -0,0 +1,69 @@
+package rules
+
+import (
+	"fmt"
+)
+
+// GetDependencies returns, for each action of the rule, the indexes of the actions it depends on.
+// The actions of a rule which is not parallel also depend on their previous action,
+// to run in the order of the rule
+func (rule *Rule) GetDependencies() ([][]int, error) {
+	index := make(map[string]int, len(rule.Actions))
+	for n, i := range rule.Actions {
+		index[i.Name] = n
+	}
+
+	dependencies := make([][]int, len(rule.Actions))
+	for n, i := range rule.Actions {
+		dependencies[n] = make([]int, 0, len(i.DependsOn)+1)
+		if !rule.IsParallel() && n > 0 {
+			dependencies[n] = append(dependencies[n], n-1)
+		}
+		for _, j := range i.DependsOn {
+			d, ok := index[j]
+			if !ok {
+				return nil, fmt.Errorf("the action '%v' depends on the unknown action '%v'", i.Name, j)
+			}
+			if d == n {
+				return nil, fmt.Errorf("the action '%v' can't depend on itself", i.Name)
+			}
+			if !rule.IsParallel() && d > n {
+				return nil, fmt.Errorf("the action '%v' can't depend on the next action '%v' in a rule which is not parallel", i.Name, j)
+			}
+			dependencies[n] = append(dependencies[n], d)
+		}
+	}
+
+	// depth-first search of a cycle
+	const (
+		unvisited int = iota
+		visiting
+		visited
+	)
+	states := make([]int, len(rule.Actions))
+	var visit func(n int) error
+	visit = func(n int) error {
+		states[n] = visiting
+		for _, d := range dependencies[n] {
+			switch states[d] {
+			case visiting:
+				return fmt.Errorf("circular dependency between the actions '%v' and '%v'", rule.Actions[n].Name, rule.Actions[d].Name)
+			case unvisited:
+				if err := visit(d); err != nil {
+					return err
+				}
+			}
+		}
+		states[n] = visited
+		return nil
+	}
+	for n := range rule.Actions {
+		if states[n] == unvisited {
+			if err := visit(n); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return dependencies, nil
+}
@@ -0,0 +1,183 @@
+package rules
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	equalStr      string = "="
+	notEqualStr   string = "!="
+	regexStr      string = "=~"
+	notRegexStr   string = "!~"
+	lowerStr      string = "<"
+	lowerEqStr    string = "<="
+	greaterStr    string = ">"
+	greaterEqStr  string = ">="
+	inStr         string = "in"
+	startswithStr string = "startswith"
+	endswithStr   string = "endswith"
+	containsStr   string = "contains"
+)
+
+var (
+	outputFieldSymbolRegex *regexp.Regexp
+	outputFieldWordRegex   *regexp.Regexp
+)
+
+func init() {
+	outputFieldSymbolRegex = regexp.MustCompile(`^([a-zA-Z0-9._\[\]]+)\s*(!=|=~|!~|<=|>=|=|<|>)\s*(.*)$`)
+	outputFieldWordRegex = regexp.MustCompile(`^([a-zA-Z0-9._\[\]]+)\s+(in|startswith|endswith|contains)\s+(.+)$`)
+}
+
+type outputfield struct {
+	Key        string
+	Comparator string
+	Value      string
+	values     []string
+	regex      *regexp.Regexp
+	number     float64
+}
+
+// parseOutputFields parses a line of 'match.output_fields', the conditions are separated by commas
+// and all of them must be satisfied for the line to match
+func parseOutputFields(line string) ([]outputfield, error) {
+	o := []outputfield{}
+	for _, i := range splitOutputFields(line) {
+		if i == "" {
+			continue
+		}
+		f, err := parseOutputField(i)
+		if err != nil {
+			return nil, err
+		}
+		o = append(o, f)
+	}
+	return o, nil
+}
+
+// splitOutputFields splits a line on the commas which are not enclosed by quotes or parentheses
+func splitOutputFields(line string) []string {
+	var s []string
+	var quoted bool
+	var depth int
+	var start int
+	for n, c := range line {
+		switch c {
+		case '"':
+			quoted = !quoted
+		case '(':
+			if !quoted {
+				depth++
+			}
+		case ')':
+			if !quoted && depth > 0 {
+				depth--
+			}
+		case ',':
+			if !quoted && depth == 0 {
+				s = append(s, strings.TrimSpace(line[start:n]))
+				start = n + 1
+			}
+		}
+	}
+	return append(s, strings.TrimSpace(line[start:]))
+}
+
+func parseOutputField(s string) (outputfield, error) {
+	if p := outputFieldWordRegex.FindStringSubmatch(s); p != nil {
+		return newOutputField(p[1], p[2], strings.TrimSpace(p[3]))
+	}
+	if p := outputFieldSymbolRegex.FindStringSubmatch(s); p != nil {
+		return newOutputField(p[1], p[2], strings.TrimSpace(p[3]))
+	}
+	return outputfield{}, fmt.Errorf("incorrect output field '%v'", s)
+}
+
+func newOutputField(key, comparator, value string) (outputfield, error) {
+	f := outputfield{Key: key, Comparator: comparator, Value: value}
+	switch f.Comparator {
+	case inStr:
+		if !strings.HasPrefix(f.Value, "(") || !strings.HasSuffix(f.Value, ")") {
+			return f, fmt.Errorf("incorrect list '%v' for the output field '%v'", f.Value, f.Key)
+		}
+		for _, i := range strings.Split(strings.TrimSuffix(strings.TrimPrefix(f.Value, "("), ")"), ",") {
+			f.values = append(f.values, strings.Trim(strings.TrimSpace(i), `"`))
+		}
+		return f, nil
+	case regexStr, notRegexStr:
+		f.Value = strings.Trim(f.Value, `"`)
+		r, err := regexp.Compile(f.Value)
+		if err != nil {
+			return f, fmt.Errorf("incorrect regular expression '%v' for the output field '%v'", f.Value, f.Key)
+		}
+		f.regex = r
+		return f, nil
+	case lowerStr, lowerEqStr, greaterStr, greaterEqStr:
+		n, err := strconv.ParseFloat(f.Value, 64)
+		if err != nil {
+			return f, fmt.Errorf("incorrect number '%v' for the output field '%v'", f.Value, f.Key)
+		}
+		f.number = n
+		return f, nil
+	case equalStr, notEqualStr:
+		f.Value = strings.ReplaceAll(f.Value, `"`, "")
+		if strings.Contains(f.Value, "*") {
+			// the value is a glob, it's converted into a regular expression
+			f.regex = regexp.MustCompile("^" + strings.ReplaceAll(regexp.QuoteMeta(f.Value), `\*`, ".*") + "$")
+		}
+		return f, nil
+	default:
+		f.Value = strings.Trim(f.Value, `"`)
+		return f, nil
+	}
+}
+
+// isNegative returns true if the comparator excludes the events with a matching value
+func (f *outputfield) isNegative() bool {
+	return f.Comparator == notEqualStr || f.Comparator == notRegexStr
+}
+
+func (f *outputfield) match(value any) bool {
+	v := fmt.Sprintf("%v", value)
+	switch f.Comparator {
+	case equalStr, notEqualStr:
+		if f.regex != nil {
+			return f.regex.MatchString(v)
+		}
+		return v == f.Value
+	case regexStr, notRegexStr:
+		return f.regex.MatchString(v)
+	case inStr:
+		for _, i := range f.values {
+			if v == i {
+				return true
+			}
+		}
+		return false
+	case startswithStr:
+		return strings.HasPrefix(v, f.Value)
+	case endswithStr:
+		return strings.HasSuffix(v, f.Value)
+	case containsStr:
+		return strings.Contains(v, f.Value)
+	case lowerStr, lowerEqStr, greaterStr, greaterEqStr:
+		n, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return false
+		}
+		switch f.Comparator {
+		case lowerStr:
+			return n < f.number
+		case lowerEqStr:
+			return n <= f.number
+		case greaterStr:
+			return n > f.number
+		default:
+			return n >= f.number
+		}
+	}
+	return false
+}
@@ -0,0 +1,96 @@
+package rules
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+)
+
+type Retry struct {
+	Backoff  string   `yaml:"backoff,omitempty"`
+	MaxDelay string   `yaml:"max_delay,omitempty"`
+	On       []string `yaml:"on,omitempty"`
+	Attempts int      `yaml:"attempts"`
+	backoff  time.Duration
+	maxDelay time.Duration
+}
+
+const (
+	defaultRetryBackoff  = 1 * time.Second
+	defaultRetryMaxDelay = 30 * time.Second
+)
+
+// kinds of errors which can be retried
+const (
+	TooManyRequestsError string = "too_many_requests"
+	ServerError          string = "server_error"
+	ConflictError        string = "conflict"
+	TimeoutError         string = "timeout"
+	OtherError           string = "other"
+	// the errors of this kind are never retried
+	NonRetryableError string = "non_retryable"
+)
+
+var retryableErrors = []string{TooManyRequestsError, ServerError, ConflictError, TimeoutError, OtherError}
+var defaultRetryOn = []string{TooManyRequestsError, ServerError, ConflictError, TimeoutError}
+
+func (r *Retry) check() error {
+	if r == nil {
+		return nil
+	}
+	if r.Attempts <= 0 {
+		return errors.New("'retry.attempts' must be greater than 0")
+	}
+	r.backoff = defaultRetryBackoff
+	if r.Backoff != "" {
+		d, err := time.ParseDuration(r.Backoff)
+		if err != nil || d <= 0 {
+			return fmt.Errorf("incorrect 'retry.backoff' '%v'", r.Backoff)
+		}
+		r.backoff = d
+	}
+	r.maxDelay = defaultRetryMaxDelay
+	if r.MaxDelay != "" {
+		d, err := time.ParseDuration(r.MaxDelay)
+		if err != nil || d <= 0 {
+			return fmt.Errorf("incorrect 'retry.max_delay' '%v'", r.MaxDelay)
+		}
+		r.maxDelay = d
+	}
+	for _, i := range r.On {
+		if !slices.Contains(retryableErrors, i) {
+			return fmt.Errorf("incorrect 'retry.on' '%v', allowed values are %v", i, retryableErrors)
+		}
+	}
+	return nil
+}
+
+// GetAttempts returns the maximum number of attempts, 1 if there's no retry
+func (r *Retry) GetAttempts() int {
+	if r == nil {
+		return 1
+	}
+	return r.Attempts
+}
+
+// IsRetryable returns true if an error of this kind has to be retried
+func (r *Retry) IsRetryable(kind string) bool {
+	if r == nil || kind == NonRetryableError {
+		return false
+	}
+	if len(r.On) == 0 {
+		return slices.Contains(defaultRetryOn, kind)
+	}
+	return slices.Contains(r.On, kind)
+}
+
+// GetDelay returns the delay before the next attempt, the backoff is doubled after each attempt
+// up to the max delay
+func (r *Retry) GetDelay(attempt int) time.Duration {
+	d := r.backoff
+	for i := 1; i < attempt && d < r.maxDelay; i++ {
+		d *= 2
+	}
+	return min(d, r.maxDelay)
+}
@@ -8,6 +8,7 @@ import (
 	"reflect"
 	"regexp"
 	"strings"
+	"time"
 
 	yaml "gopkg.in/yaml.v3"
 
@@ -16,29 +17,46 @@ import (
 )
 
 type Action struct {
-	Output             Output         `yaml:"output,omitempty"`
-	Parameters         map[string]any `yaml:"parameters,omitempty"`
-	Name               string         `yaml:"action"`
-	Description        string         `yaml:"description"`
-	Actionner          string         `yaml:"actionner"`
-	Continue           string         `yaml:"continue,omitempty"`      // can't be a bool because an omitted value == false by default
-	IgnoreErrors       string         `yaml:"ignore_errors,omitempty"` // can't be a bool because an omitted value == false by default
-	AdditionalContexts []string       `yaml:"additional_contexts,omitempty"`
+	Output              Output         `yaml:"output,omitempty"`
+	Parameters          map[string]any `yaml:"parameters,omitempty"`
+	Name                string         `yaml:"action"`
+	Description         string         `yaml:"description"`
+	Actionner           string         `yaml:"actionner"`
+	Continue            string         `yaml:"continue,omitempty"`      // can't be a bool because an omitted value == false by default
+	IgnoreErrors        string         `yaml:"ignore_errors,omitempty"` // can't be a bool because an omitted value == false by default
+	Approval            string         `yaml:"approval,omitempty"`
+	InterpolateCommands string         `yaml:"interpolate_commands,omitempty"` // can't be a bool because an omitted value == false by default
+	Timeout             string         `yaml:"timeout,omitempty"`
+	AdditionalContexts  []string       `yaml:"additional_contexts,omitempty"`
+	DependsOn           []string       `yaml:"depends_on,omitempty"`
+	RateLimit           *RateLimit     `yaml:"rate_limit,omitempty"`
+	Cooldown            *Cooldown      `yaml:"cooldown,omitempty"`
+	Retry               *Retry         `yaml:"retry,omitempty"`
 }
 
 type Rule struct {
-	Name        string    `yaml:"rule"`
-	Description string    `yaml:"description"`
-	Continue    string    `yaml:"continue"`          // can't be a bool because an omitted value == false by default
-	DryRun      string    `yaml:"dry_run,omitempty"` // can't be a bool because an omitted value == false by default
-	Actions     []*Action `yaml:"actions"`
-	Notifiers   []string  `yaml:"notifiers"`
-	Match       Match     `yaml:"match"`
+	Name          string         `yaml:"rule"`
+	Description   string         `yaml:"description"`
+	Continue      string         `yaml:"continue"`           // can't be a bool because an omitted value == false by default
+	DryRun        string         `yaml:"dry_run,omitempty"`  // can't be a bool because an omitted value == false by default
+	Parallel      string         `yaml:"parallel,omitempty"` // can't be a bool because an omitted value == false by default
+	Actions       []*Action      `yaml:"actions"`
+	Notifiers     []string       `yaml:"notifiers"`
+	Match         Match          `yaml:"match"`
+	RateLimit     *RateLimit     `yaml:"rate_limit,omitempty"`
+	Cooldown      *Cooldown      `yaml:"cooldown,omitempty"`
+	Deduplication *Deduplication `yaml:"deduplication,omitempty"`
+}
+
+type Deduplication struct {
+	Key []string `yaml:"key"`
 }
 
 type Match struct {
+	ConditionC         condition
 	OutputFields       []string `yaml:"output_fields"`
 	OutputFieldsC      [][]outputfield
+	Condition          string `yaml:"condition,omitempty"`
 	PriorityComparator string
 	Priority           string   `yaml:"priority,omitempty"`
 	Source             string   `yaml:"source,omitempty"`
@@ -50,29 +68,27 @@ type Match struct {
 
 type Output struct {
 	Parameters map[string]any `yaml:"parameters"`
-	Target     string         `yaml:"target"`
-}
-
-type outputfield struct {
-	Key        string
-	Comparator string
-	Value      string
+	Retry      *Retry         `yaml:"retry,omitempty"`
+	// templates are the parameters before their rendering
+	templates map[string]any
+	Target    string `yaml:"target"`
 }
 
 const (
 	trueStr                 string = "true"
 	falseStr                string = "false"
 	falcoTalonContextPrefix string = "falco-talon."
+	requiredStr             string = "required"
+	noneStr                 string = "none"
 )
 
 var rules *[]*Rule
 
 var (
-	priorityCheckRegex       *regexp.Regexp
-	actionCheckRegex         *regexp.Regexp
-	priorityComparatorRegex  *regexp.Regexp
-	tagCheckRegex            *regexp.Regexp
-	outputFieldKeyCheckRegex *regexp.Regexp
+	priorityCheckRegex      *regexp.Regexp
+	actionCheckRegex        *regexp.Regexp
+	priorityComparatorRegex *regexp.Regexp
+	tagCheckRegex           *regexp.Regexp
 )
 
 func init() {
@@ -80,7 +96,6 @@ func init() {
 	actionCheckRegex = regexp.MustCompile(`[a-z]+:[a-z]+`)
 	priorityComparatorRegex = regexp.MustCompile(`^(<|>)?(=)?`)
 	tagCheckRegex = regexp.MustCompile(`(?i)^[a-z_0-9.]*[a-z0-9]$`)
-	outputFieldKeyCheckRegex = regexp.MustCompile(`(?i)^[a-z0-9.\[\]]*(!)?(=)`)
 
 	rules = new([]*Rule)
 }
@@ -108,6 +123,27 @@ func ParseRules(files []string) *[]*Rule {
 					if rule.Actions[n].Continue == "" && action.Continue != "" {
 						rule.Actions[n].Continue = action.Continue
 					}
+					if rule.Actions[n].Approval == "" && action.Approval != "" {
+						rule.Actions[n].Approval = action.Approval
+					}
+					if rule.Actions[n].InterpolateCommands == "" && action.InterpolateCommands != "" {
+						rule.Actions[n].InterpolateCommands = action.InterpolateCommands
+					}
+					if rule.Actions[n].Timeout == "" && action.Timeout != "" {
+						rule.Actions[n].Timeout = action.Timeout
+					}
+					if rule.Actions[n].RateLimit == nil && action.RateLimit != nil {
+						rule.Actions[n].RateLimit = action.RateLimit
+					}
+					if rule.Actions[n].Cooldown == nil && action.Cooldown != nil {
+						rule.Actions[n].Cooldown = action.Cooldown
+					}
+					if rule.Actions[n].Retry == nil && action.Retry != nil {
+						rule.Actions[n].Retry = action.Retry
+					}
+					if len(rule.Actions[n].DependsOn) == 0 && len(action.DependsOn) != 0 {
+						rule.Actions[n].DependsOn = action.DependsOn
+					}
 					if len(rule.Actions[n].AdditionalContexts) == 0 && len(action.AdditionalContexts) != 0 {
 						rule.Actions[n].AdditionalContexts = make([]string, len(action.AdditionalContexts))
 						rule.Actions[n].AdditionalContexts = action.AdditionalContexts
@@ -150,6 +186,9 @@ func ParseRules(files []string) *[]*Rule {
 					if rule.Actions[n].Output.Target == "" && action.Output.Target != "" {
 						rule.Actions[n].Output.Target = action.Output.Target
 					}
+					if rule.Actions[n].Output.Retry == nil && action.Output.Retry != nil {
+						rule.Actions[n].Output.Retry = action.Output.Retry
+					}
 					for k, v := range action.Output.Parameters {
 						rt := reflect.TypeOf(v)
 						ru := reflect.TypeOf(rule.Actions[n].Output.Parameters[k])
@@ -193,24 +232,15 @@ func ParseRules(files []string) *[]*Rule {
 			rule.Match.TagsC = append(rule.Match.TagsC, t)
 		}
 		for _, j := range rule.Match.OutputFields {
-			t := strings.Split(strings.ReplaceAll(strings.ReplaceAll(j, "!=", "!"), ", ", ","), ",")
-			o := []outputfield{}
-			for _, k := range t {
-				if strings.Contains(k, "=") {
-					p := strings.Split(k, "=")
-					if len(p) == 2 {
-						o = append(o, outputfield{p[0], "=", strings.ReplaceAll(p[1], `"`, "")})
-					}
-				}
-				if strings.Contains(k, "!") {
-					p := strings.Split(k, "!")
-					if len(p) == 2 {
-						o = append(o, outputfield{p[0], "!=", strings.ReplaceAll(p[1], `"`, "")})
-					}
-				}
+			o, err := parseOutputFields(j)
+			if err != nil {
+				continue // the error is reported by isValid()
 			}
 			rule.Match.OutputFieldsC = append(rule.Match.OutputFieldsC, o)
 		}
+		if c, err := parseCondition(rule.Match.Condition); err == nil {
+			rule.Match.ConditionC = c
+		}
 	}
 
 	valid := true // to check the validity of the rules
@@ -277,6 +307,30 @@ func extractActionsRules(files []string) (*[]*Action, *[]*Rule, error) {
 				if l.IgnoreErrors != "" {
 					i.IgnoreErrors = l.IgnoreErrors
 				}
+				if l.Approval != "" {
+					i.Approval = l.Approval
+				}
+				if l.InterpolateCommands != "" {
+					i.InterpolateCommands = l.InterpolateCommands
+				}
+				if l.Timeout != "" {
+					i.Timeout = l.Timeout
+				}
+				if len(l.DependsOn) != 0 {
+					i.DependsOn = l.DependsOn
+				}
+				if l.RateLimit != nil {
+					i.RateLimit = l.RateLimit
+				}
+				if l.Cooldown != nil {
+					i.Cooldown = l.Cooldown
+				}
+				if l.Retry != nil {
+					i.Retry = l.Retry
+				}
+				if l.Output.Retry != nil {
+					i.Output.Retry = l.Output.Retry
+				}
 				if i.Parameters == nil && len(l.Parameters) != 0 {
 					i.Parameters = make(map[string]any)
 				}
@@ -355,11 +409,26 @@ func extractActionsRules(files []string) (*[]*Action, *[]*Rule, error) {
 				if l.DryRun != "" {
 					i.DryRun = l.DryRun
 				}
+				if l.Parallel != "" {
+					i.Parallel = l.Parallel
+				}
+				if l.RateLimit != nil {
+					i.RateLimit = l.RateLimit
+				}
+				if l.Cooldown != nil {
+					i.Cooldown = l.Cooldown
+				}
+				if l.Deduplication != nil {
+					i.Deduplication = l.Deduplication
+				}
 				if l.Description != "" {
 					i.Description = l.Description
 				}
 				i.Notifiers = append(i.Notifiers, l.Notifiers...)
 				i.Match.OutputFields = append(i.Match.OutputFields, l.Match.OutputFields...)
+				if l.Match.Condition != "" {
+					i.Match.Condition = l.Match.Condition
+				}
 				i.Match.Priority = l.Match.Priority
 				i.Match.Source = l.Match.Source
 				i.Match.Rules = append(i.Match.Rules, l.Match.Rules...)
@@ -401,10 +470,38 @@ func (rule *Rule) isValid() bool {
 		utils.PrintLog("error", utils.LogLine{Error: "'dry_run' setting can be 'true' or 'false' only", Message: "rules", Rule: rule.Name})
 		valid = false
 	}
+	if rule.Parallel != "" && rule.Parallel != trueStr && rule.Parallel != falseStr {
+		utils.PrintLog("error", utils.LogLine{Error: "'parallel' setting can be 'true' or 'false' only", Message: "rules", Rule: rule.Name})
+		valid = false
+	}
 	if len(rule.Actions) == 0 {
 		utils.PrintLog("error", utils.LogLine{Error: "no action specified", Message: "rules", Rule: rule.Name})
 		valid = false
 	}
+	if _, err := rule.GetDependencies(); err != nil {
+		utils.PrintLog("error", utils.LogLine{Error: err.Error(), Message: "rules", Rule: rule.Name})
+		valid = false
+	}
+	if err := rule.RateLimit.check(); err != nil {
+		utils.PrintLog("error", utils.LogLine{Error: err.Error(), Message: "rules", Rule: rule.Name})
+		valid = false
+	}
+	if err := rule.Cooldown.check(); err != nil {
+		utils.PrintLog("error", utils.LogLine{Error: err.Error(), Message: "rules", Rule: rule.Name})
+		valid = false
+	}
+	if rule.Deduplication != nil {
+		if len(rule.Deduplication.Key) == 0 {
+			utils.PrintLog("error", utils.LogLine{Error: "'deduplication.key' must contain at least one field", Message: "rules", Rule: rule.Name})
+			valid = false
+		}
+		for _, i := range rule.Deduplication.Key {
+			if strings.TrimSpace(i) == "" {
+				utils.PrintLog("error", utils.LogLine{Error: "'deduplication.key' can't contain an empty field", Message: "rules", Rule: rule.Name})
+				valid = false
+			}
+		}
+	}
 	if len(rule.Actions) != 0 {
 		for _, i := range rule.Actions {
 			if i.Name == "" {
@@ -427,6 +524,44 @@ func (rule *Rule) isValid() bool {
 				utils.PrintLog("error", utils.LogLine{Error: "'ignore_errors' setting can be 'true' or 'false' only", Message: "rules", Action: i.Name, Actionner: i.Actionner, Rule: rule.Name})
 				valid = false
 			}
+			if i.InterpolateCommands != "" && i.InterpolateCommands != trueStr && i.InterpolateCommands != falseStr {
+				utils.PrintLog("error", utils.LogLine{Error: "'interpolate_commands' setting can be 'true' or 'false' only", Message: "rules", Action: i.Name, Actionner: i.Actionner, Rule: rule.Name})
+				valid = false
+			}
+			if i.Approval != "" && i.Approval != requiredStr && i.Approval != noneStr {
+				utils.PrintLog("error", utils.LogLine{Error: "'approval' setting can be 'required' or 'none' only", Message: "rules", Action: i.Name, Actionner: i.Actionner, Rule: rule.Name})
+				valid = false
+			}
+			if i.Timeout != "" {
+				if d, err := time.ParseDuration(i.Timeout); err != nil || d <= 0 {
+					utils.PrintLog("error", utils.LogLine{Error: fmt.Sprintf("incorrect 'timeout' '%v'", i.Timeout), Message: "rules", Action: i.Name, Actionner: i.Actionner, Rule: rule.Name})
+					valid = false
+				}
+			}
+			if err := i.RateLimit.check(); err != nil {
+				utils.PrintLog("error", utils.LogLine{Error: err.Error(), Message: "rules", Action: i.Name, Actionner: i.Actionner, Rule: rule.Name})
+				valid = false
+			}
+			if err := i.Cooldown.check(); err != nil {
+				utils.PrintLog("error", utils.LogLine{Error: err.Error(), Message: "rules", Action: i.Name, Actionner: i.Actionner, Rule: rule.Name})
+				valid = false
+			}
+			if err := i.Retry.check(); err != nil {
+				utils.PrintLog("error", utils.LogLine{Error: err.Error(), Message: "rules", Action: i.Name, Actionner: i.Actionner, Rule: rule.Name})
+				valid = false
+			}
+			if err := i.Output.Retry.check(); err != nil {
+				utils.PrintLog("error", utils.LogLine{Error: err.Error(), Message: "rules", Action: i.Name, Actionner: i.Actionner, Rule: rule.Name, OutputTarget: i.Output.Target})
+				valid = false
+			}
+			if err := checkTemplates(i.Parameters, i.InterpolateCommands == trueStr); err != nil {
+				utils.PrintLog("error", utils.LogLine{Error: err.Error(), Message: "rules", Action: i.Name, Actionner: i.Actionner, Rule: rule.Name})
+				valid = false
+			}
+			if err := checkTemplates(i.Output.Parameters, true); err != nil {
+				utils.PrintLog("error", utils.LogLine{Error: err.Error(), Message: "rules", Action: i.Name, Actionner: i.Actionner, Rule: rule.Name, OutputTarget: i.Output.Target})
+				valid = false
+			}
 			if i.Output.Target != "" && len(i.Output.Parameters) == 0 {
 				utils.PrintLog("error", utils.LogLine{Error: "missing 'parameters' for the output", Message: "rules", Action: i.Name, Actionner: i.Actionner, Rule: rule.Name, OutputTarget: i.Output.Target})
 				valid = false
@@ -446,14 +581,15 @@ func (rule *Rule) isValid() bool {
 		}
 	}
 	for _, i := range rule.Match.OutputFields {
-		t := strings.Split(strings.ReplaceAll(i, ", ", ","), ",")
-		for _, j := range t {
-			if !outputFieldKeyCheckRegex.MatchString(j) {
-				utils.PrintLog("error", utils.LogLine{Error: fmt.Sprintf("incorrect output field key '%v'", j), Message: "rules", Rule: rule.Name})
-				valid = false
-			}
+		if _, err := parseOutputFields(i); err != nil {
+			utils.PrintLog("error", utils.LogLine{Error: err.Error(), Message: "rules", Rule: rule.Name})
+			valid = false
 		}
 	}
+	if _, err := parseCondition(rule.Match.Condition); err != nil {
+		utils.PrintLog("error", utils.LogLine{Error: fmt.Sprintf("incorrect condition '%v': %v", rule.Match.Condition, err.Error()), Message: "rules", Rule: rule.Name})
+		valid = false
+	}
 	if err := rule.setPriorityNumberComparator(); err != nil {
 		utils.PrintLog("error", utils.LogLine{Error: fmt.Sprintf("incorrect priority comparator '%v'", rule.Match.PriorityComparator), Message: "rules", Rule: rule.Name})
 		valid = false
@@ -486,6 +622,18 @@ func (rule *Rule) GetActions() []*Action {
 	return rule.Actions
 }
 
+// IsParallel returns true if the actions of the rule without dependencies between them run concurrently
+func (rule *Rule) IsParallel() bool {
+	return rule.Parallel == trueStr
+}
+
+func (rule *Rule) GetDeduplicationKey() []string {
+	if rule.Deduplication == nil {
+		return nil
+	}
+	return rule.Deduplication.Key
+}
+
 func (rule *Rule) ListNotifiers() []string {
 	return rule.Notifiers
 }
@@ -518,6 +666,17 @@ func (action *Action) GetAdditionalContexts() []string {
 	return action.AdditionalContexts
 }
 
+// RequireApproval returns true if the action has to be approved before its execution
+func (action *Action) RequireApproval() bool {
+	return action.Approval == requiredStr
+}
+
+// GetTimeout returns the maximum duration of the action, 0 if there's none
+func (action *Action) GetTimeout() time.Duration {
+	d, _ := time.ParseDuration(action.Timeout) // can't trigger an error, cause the value is validated before
+	return d
+}
+
 func (action *Action) GetOutput() *Output {
 	if action.Output.Target == "" {
 		return nil
@@ -549,6 +708,9 @@ func (rule *Rule) CompareRule(event *events.Event) bool {
 	if !rule.compareSource(event) {
 		return false
 	}
+	if !rule.compareCondition(event) {
+		return false
+	}
 	return true
 }
 
@@ -569,23 +731,22 @@ func (rule *Rule) compareOutputFields(event *events.Event) bool {
 		return true
 	}
 	for _, i := range rule.Match.OutputFieldsC {
-		var countK, countV, countF int
-		for _, j := range i {
-			if j.Comparator == "=" {
-				countK++
-			}
-		}
+		match := true
 		for _, j := range i {
-			for k, v := range event.OutputFields {
-				if j.Comparator == "!=" && j.Key == k && j.Value == fmt.Sprintf("%v", v) {
-					countF++
-				}
-				if j.Comparator == "=" && j.Key == k && j.Value == fmt.Sprintf("%v", v) {
-					countV++
+			v, ok := event.OutputFields[j.Key]
+			if j.isNegative() {
+				if ok && j.match(v) {
+					match = false
+					break
 				}
+				continue
+			}
+			if !ok || !j.match(v) {
+				match = false
+				break
 			}
 		}
-		if countK == countV && countF == 0 {
+		if match {
 			return true
 		}
 	}
@@ -619,6 +780,13 @@ func (rule *Rule) compareSource(event *events.Event) bool {
 	return event.Source == rule.Match.Source
 }
 
+func (rule *Rule) compareCondition(event *events.Event) bool {
+	if rule.Match.ConditionC == nil {
+		return true
+	}
+	return rule.Match.ConditionC.eval(event)
+}
+
 func (rule *Rule) comparePriority(event *events.Event) bool {
 	if rule.Match.PriorityNumber == 0 {
 		return true
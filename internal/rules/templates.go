@@ -0,0 +1,161 @@
+package rules
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/falcosecurity/falco-talon/internal/events"
+)
+
+const objectsPrefix string = "objects."
+
+// commandParameters are the parameters run by a shell, their ${...} are the ones of the shell unless the action
+// sets interpolate_commands
+var commandParameters = []string{"command", "script"}
+
+// checkTemplates returns an error if a string of the parameters contains a malformed ${field} placeholder, the
+// commands are checked only if they're interpolated
+func checkTemplates(parameters map[string]any, commands bool) error {
+	for k, v := range parameters {
+		if !commands && slices.Contains(commandParameters, k) {
+			continue
+		}
+		if err := checkTemplate(v); err != nil {
+			return fmt.Errorf("incorrect parameter '%v': %v", k, err.Error())
+		}
+	}
+	return nil
+}
+
+func checkTemplate(v any) error {
+	switch t := v.(type) {
+	case string:
+		return events.CheckTemplate(t)
+	case map[string]any:
+		for _, i := range t {
+			if err := checkTemplate(i); err != nil {
+				return err
+			}
+		}
+	case []any:
+		for _, i := range t {
+			if err := checkTemplate(i); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Render returns a copy of the action with the ${field} placeholders of its parameters
+// replaced by the values of the fields of the event. The commands are kept as is, unless the action sets
+// interpolate_commands, their substituted values are then quoted for the shell
+func (action *Action) Render(event *events.Event) *Action {
+	if len(action.Parameters) == 0 {
+		return action
+	}
+	a := *action
+	a.Parameters = make(map[string]any, len(action.Parameters))
+	for k, v := range action.Parameters {
+		switch {
+		case !slices.Contains(commandParameters, k):
+			a.Parameters[k] = render(v, event, nil, nil)
+		case action.InterpolateCommands == trueStr:
+			a.Parameters[k] = render(v, event, nil, quoteShell)
+		default:
+			a.Parameters[k] = v
+		}
+	}
+	return &a
+}
+
+// Render returns a copy of the output with the ${field} placeholders of its parameters
+// replaced by the values of the fields of the event and of the objects reported by the actionner.
+// The shortcuts ${date}, ${namespace} and ${pod} and the objects as ${objects.key} are also available
+func (output *Output) Render(event *events.Event, objects map[string]string) *Output {
+	if len(output.Parameters) == 0 {
+		return output
+	}
+	extra := map[string]string{
+		"date":      time.Now().UTC().Format("2006-01-02"),
+		"namespace": event.GetNamespaceName(),
+		"pod":       event.GetPodName(),
+	}
+	for k, v := range objects {
+		extra[objectsPrefix+k] = v
+	}
+	o := *output
+	o.Parameters = render(output.Parameters, event, extra, escapePath).(map[string]any)
+	o.templates = output.Parameters
+	return &o
+}
+
+func render(v any, event *events.Event, extra map[string]string, escape func(string) string) any {
+	switch t := v.(type) {
+	case string:
+		return event.InterpolateEscaped(t, extra, escape)
+	case map[string]any:
+		m := make(map[string]any, len(t))
+		for k, i := range t {
+			m[k] = render(i, event, extra, escape)
+		}
+		return m
+	case []any:
+		s := make([]any, 0, len(t))
+		for _, i := range t {
+			s = append(s, render(i, event, extra, escape))
+		}
+		return s
+	}
+	return v
+}
+
+// escapePath escapes the values substituted in the parameters of the outputs, they come from the events
+// and must not add directories or go up in the destinations, the prefixes and the keys
+func escapePath(v string) string {
+	v = strings.ReplaceAll(v, "/", "_")
+	v = strings.ReplaceAll(v, "\\", "_")
+	return strings.ReplaceAll(v, "..", "_")
+}
+
+// quoteShell quotes the values substituted in the commands, they come from the events and must not be run by
+// the shell; the placeholders mustn't be quoted again in the commands
+func quoteShell(v string) string {
+	return "'" + strings.ReplaceAll(v, "'", `'\''`) + "'"
+}
+
+// CheckPath returns the cleaned path p, or an error if it's outside of the static directory of the parameter,
+// which is the part of the parameter before its first ${field} placeholder, as written in the rule
+func (output *Output) CheckPath(name, p string) (string, error) {
+	parameters := output.templates
+	if parameters == nil {
+		parameters = output.Parameters
+	}
+	template, _ := parameters[name].(string)
+	dir := template
+	if n := strings.Index(template, "${"); n >= 0 {
+		dir = template[:n]
+		if !strings.HasSuffix(dir, "/") {
+			dir = path.Dir(dir)
+		}
+	}
+	dir = path.Clean(dir)
+	cleaned := path.Clean(p)
+
+	var ok bool
+	switch dir {
+	case ".":
+		ok = cleaned != ".." && !strings.HasPrefix(cleaned, "../")
+	case "/":
+		ok = path.IsAbs(cleaned)
+	default:
+		ok = cleaned == dir || strings.HasPrefix(cleaned, dir+"/")
+	}
+	if !ok {
+		return "", fmt.Errorf("the path '%v' is outside of '%v'", p, dir)
+	}
+	return cleaned, nil
+}
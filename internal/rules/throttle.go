@@ -0,0 +1,103 @@
+package rules
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/falcosecurity/falco-talon/internal/events"
+	"github.com/falcosecurity/falco-talon/internal/throttle"
+)
+
+type RateLimit struct {
+	Key    string `yaml:"key,omitempty"`
+	Period string `yaml:"period"`
+	Max    int    `yaml:"max"`
+	period time.Duration
+}
+
+type Cooldown struct {
+	Key      string `yaml:"key,omitempty"`
+	Duration string `yaml:"duration"`
+	duration time.Duration
+}
+
+// throttleMutex makes the check and the record of the executions atomic
+var throttleMutex sync.Mutex
+
+func (r *RateLimit) check() error {
+	if r == nil {
+		return nil
+	}
+	if r.Max <= 0 {
+		return errors.New("'rate_limit.max' must be greater than 0")
+	}
+	d, err := time.ParseDuration(r.Period)
+	if err != nil || d <= 0 {
+		return fmt.Errorf("incorrect 'rate_limit.period' '%v'", r.Period)
+	}
+	if err := events.CheckTemplate(r.Key); err != nil {
+		return fmt.Errorf("incorrect 'rate_limit.key': %v", err.Error())
+	}
+	r.period = d
+	return nil
+}
+
+func (c *Cooldown) check() error {
+	if c == nil {
+		return nil
+	}
+	d, err := time.ParseDuration(c.Duration)
+	if err != nil || d <= 0 {
+		return fmt.Errorf("incorrect 'cooldown.duration' '%v'", c.Duration)
+	}
+	if err := events.CheckTemplate(c.Key); err != nil {
+		return fmt.Errorf("incorrect 'cooldown.key': %v", err.Error())
+	}
+	c.duration = d
+	return nil
+}
+
+// IsThrottled returns true if the rate limit or the cooldown of the rule is reached for the event,
+// otherwise the execution is recorded
+func (rule *Rule) IsThrottled(event *events.Event) bool {
+	return isThrottled("rule:"+rule.Name, rule.RateLimit, rule.Cooldown, event)
+}
+
+// IsThrottled returns true if the rate limit or the cooldown of the action is reached for the event,
+// otherwise the execution is recorded
+func (action *Action) IsThrottled(rule *Rule, event *events.Event) bool {
+	return isThrottled("action:"+rule.Name+":"+action.Name, action.RateLimit, action.Cooldown, event)
+}
+
+func isThrottled(prefix string, rateLimit *RateLimit, cooldown *Cooldown, event *events.Event) bool {
+	if rateLimit == nil && cooldown == nil {
+		return false
+	}
+
+	throttleMutex.Lock()
+	defer throttleMutex.Unlock()
+
+	var rateLimitKey, cooldownKey string
+	if rateLimit != nil {
+		rateLimitKey = prefix + ":rate_limit:" + event.Interpolate(rateLimit.Key)
+		if !throttle.Check(rateLimitKey, rateLimit.Max, rateLimit.period) {
+			return true
+		}
+	}
+	if cooldown != nil {
+		cooldownKey = prefix + ":cooldown:" + event.Interpolate(cooldown.Key)
+		if !throttle.Check(cooldownKey, 1, cooldown.duration) {
+			return true
+		}
+	}
+
+	if rateLimit != nil {
+		throttle.Record(rateLimitKey, rateLimit.period)
+	}
+	if cooldown != nil {
+		throttle.Record(cooldownKey, cooldown.duration)
+	}
+	return false
+}
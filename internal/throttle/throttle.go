@@ -0,0 +1,80 @@
+package throttle
+
+import (
+	"sync"
+	"time"
+)
+
+// sweepInterval is the minimal delay between two evictions of the expired keys
+const sweepInterval = time.Minute
+
+type entry struct {
+	times  []time.Time
+	period time.Duration
+}
+
+type store struct {
+	entries   map[string]*entry
+	lastSweep time.Time
+	sync.Mutex
+}
+
+var s *store
+
+func init() {
+	s = &store{entries: make(map[string]*entry), lastSweep: time.Now()}
+}
+
+// Check returns true if less than 'limit' executions have been recorded for the key during the last 'period'
+func Check(key string, limit int, period time.Duration) bool {
+	s.Lock()
+	defer s.Unlock()
+
+	s.sweep()
+	return len(s.prune(key, period)) < limit
+}
+
+// Record adds an execution for the key, the entries older than 'period' are removed
+func Record(key string, period time.Duration) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.sweep()
+	s.entries[key] = &entry{times: append(s.prune(key, period), time.Now()), period: period}
+}
+
+func (s *store) prune(key string, period time.Duration) []time.Time {
+	limit := time.Now().Add(-period)
+	e, ok := s.entries[key]
+	if !ok {
+		return nil
+	}
+	n := 0
+	for _, i := range e.times {
+		if i.After(limit) {
+			e.times[n] = i
+			n++
+		}
+	}
+	if n == 0 {
+		delete(s.entries, key)
+		return nil
+	}
+	e.times = e.times[:n]
+	return e.times
+}
+
+// sweep periodically removes the keys with all their executions older than their period, the keys are
+// built from the fields of the events, they would accumulate otherwise
+func (s *store) sweep() {
+	now := time.Now()
+	if now.Sub(s.lastSweep) < sweepInterval {
+		return
+	}
+	s.lastSweep = now
+	for i, j := range s.entries {
+		if len(j.times) == 0 || now.Sub(j.times[len(j.times)-1]) > j.period {
+			delete(s.entries, i)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package ttl
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	// Label marks the objects with an expiration, to find them with a label selector
+	Label string = "falco-talon.falcosecurity.org/ttl"
+	// ExpiresAtAnnotation is the date of expiration of an object created by Falco Talon
+	ExpiresAtAnnotation string = "falco-talon.falcosecurity.org/expires-at"
+	// CordonAnnotation is the date of expiration of the cordon of a node
+	CordonAnnotation string = "falco-talon.falcosecurity.org/cordon-expires-at"
+	// LabelsAnnotation contains the dates of expiration and the previous values of the labels set by Falco Talon
+	LabelsAnnotation string = "falco-talon.falcosecurity.org/labels-expiration"
+	// ScaleAnnotation is the date of expiration of the scaling of a workload
+	ScaleAnnotation string = "falco-talon.falcosecurity.org/scale-expires-at"
+	// BlocklistAnnotation contains the dates of expiration of the CIDRs blocked by Falco Talon
+	BlocklistAnnotation string = "falco-talon.falcosecurity.org/blocklist-expiration"
+
+	trueStr string = "true"
+)
+
+// Check returns an error if the ttl isn't a valid duration
+func Check(ttl string) error {
+	if ttl == "" {
+		return nil
+	}
+	d, err := time.ParseDuration(ttl)
+	if err != nil || d <= 0 {
+		return fmt.Errorf("incorrect ttl '%v'", ttl)
+	}
+	return nil
+}
+
+// ExpiresAt returns the date of expiration for the ttl, from now
+func ExpiresAt(ttl string) string {
+	d, _ := time.ParseDuration(ttl)
+	return time.Now().UTC().Add(d).Format(time.RFC3339)
+}
+
+// Latest returns the latest of two dates of expiration
+func Latest(a, b string) string {
+	ta, err := time.Parse(time.RFC3339, a)
+	if err != nil {
+		return b
+	}
+	tb, err := time.Parse(time.RFC3339, b)
+	if err != nil || ta.After(tb) {
+		return a
+	}
+	return b
+}
+
+// IsExpired returns true if the date of expiration is reached
+func IsExpired(expiresAt string) bool {
+	t, err := time.Parse(time.RFC3339, expiresAt)
+	if err != nil {
+		return false
+	}
+	return time.Now().After(t)
+}
+
+// Update returns the value of the annotation for a new action on an existing object: a ttl extends the
+// current expiration, no ttl makes the object permanent, an already permanent object stays permanent
+func Update(current string, exists bool, ttl string) (string, bool) {
+	if ttl == "" || !exists {
+		return "", false
+	}
+	return Latest(current, ExpiresAt(ttl)), true
+}
+
+// MarkerValue returns the value of the marker label for the annotations of an object, nil to remove it
+func MarkerValue(annotations map[string]string) *string {
+	for _, i := range []string{ExpiresAtAnnotation, CordonAnnotation, LabelsAnnotation, ScaleAnnotation, BlocklistAnnotation} {
+		if _, ok := annotations[i]; ok {
+			v := trueStr
+			return &v
+		}
+	}
+	return nil
+}
+
+// Selector is the label selector of the objects with an expiration
+func Selector() string {
+	return Label + "=" + trueStr
+}
+
+// SetExpiration returns the annotations of an object created or updated by an action, with its date of
+// expiration, and sets the marker label; 'current' are the annotations of the existing object
+func SetExpiration(labels, current map[string]string, created bool, ttl string) map[string]string {
+	annotations := make(map[string]string, len(current)+1)
+	for i, j := range current {
+		annotations[i] = j
+	}
+	if created {
+		if ttl != "" {
+			annotations[ExpiresAtAnnotation] = ExpiresAt(ttl)
+		}
+	} else {
+		v, ok := current[ExpiresAtAnnotation]
+		if expiresAt, keep := Update(v, ok, ttl); keep {
+			annotations[ExpiresAtAnnotation] = expiresAt
+		} else {
+			delete(annotations, ExpiresAtAnnotation)
+		}
+	}
+	if MarkerValue(annotations) != nil {
+		labels[Label] = trueStr
+	} else {
+		delete(labels, Label)
+	}
+	return annotations
+}
+
+// MetadataPatch returns the metadata of a merge patch setting an annotation, or removing it if the value is nil,
+// with the marker label; 'current' are the annotations of the existing object
+func MetadataPatch(current map[string]string, key string, value *string) map[string]any {
+	annotations := make(map[string]string, len(current)+1)
+	for i, j := range current {
+		annotations[i] = j
+	}
+	if value != nil {
+		annotations[key] = *value
+	} else {
+		delete(annotations, key)
+	}
+	return map[string]any{
+		"annotations": map[string]*string{key: value},
+		"labels":      map[string]*string{Label: MarkerValue(annotations)},
+	}
+}
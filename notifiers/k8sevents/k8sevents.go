@@ -112,7 +112,7 @@ func (n Notifier) Run(log utils.LogLine) error {
 	client := kubernetes.GetClient()
 
 	namespace := log.Objects["namespace"]
-	ns, err := client.GetNamespace(namespace)
+	ns, err := client.GetNamespace(context.Background(), namespace)
 	if err != nil {
 		namespace = defaultStr
 	}
@@ -24,12 +24,13 @@ const (
   output:
     target: local:file
     parameters:
-      destination: /var/logs/falco-talon/
+      destination: /var/logs/falco-talon/${date}/${namespace}/${pod}/${trace_id}
 `
 )
 
 type Parameters struct {
 	Destination string `mapstructure:"destination" validate:"required"`
+	Key         string `mapstructure:"key" validate:"omitempty"`
 }
 
 type Output struct{}
@@ -53,6 +54,7 @@ func (o Output) Information() models.Information {
 func (o Output) Parameters() models.Parameters {
 	return Parameters{
 		Destination: "",
+		Key:         "",
 	}
 }
 
@@ -63,7 +65,12 @@ func (o Output) Checks(output *rules.Output) error {
 		return err
 	}
 
-	dstFolder := os.ExpandEnv(parameters.Destination)
+	// the folders with placeholders are created when the file is written
+	dstFolder := parameters.Destination
+	if s, _, found := strings.Cut(dstFolder, "${"); found {
+		dstFolder = filepath.Dir(s)
+	}
+	dstFolder = os.ExpandEnv(dstFolder)
 	if _, err := os.Open(dstFolder); os.IsNotExist(err) {
 		return fmt.Errorf("folder '%v' does not exist", dstFolder)
 	}
@@ -82,8 +89,9 @@ func (o Output) Run(output *rules.Output, data *models.Data) (utils.LogLine, err
 		}, err
 	}
 
-	var key string
+	key := parameters.Key
 	switch {
+	case key != "":
 	case data.Objects["namespace"] != "" && data.Objects["pod"] != "":
 		key = fmt.Sprintf("%v_%v_%v_%v", time.Now().Format("2006-01-02T15-04-05Z"), data.Objects["namespace"], data.Objects["pod"], strings.ReplaceAll(data.Name, "/", "_"))
 	case data.Objects["hostname"] != "":
@@ -98,13 +106,30 @@ func (o Output) Run(output *rules.Output, data *models.Data) (utils.LogLine, err
 		key = fmt.Sprintf("%v_%v%v", time.Now().Format("2006-01-02T15-04-05Z"), s, strings.ReplaceAll(data.Name, "/", "_"))
 	}
 
-	dstfile := fmt.Sprintf("%v/%v", strings.TrimSuffix(parameters.Destination, "/"), key)
+	// the values of the events are escaped in the parameters, the destination and the key from the rule
+	// are still checked to not write outside of the destination
+	dstfile, err := output.CheckPath("destination", fmt.Sprintf("%v/%v", strings.TrimSuffix(parameters.Destination, "/"), key))
+	if err != nil {
+		return utils.LogLine{
+			Objects: map[string]string{"file": data.Name},
+			Error:   err.Error(),
+			Status:  utils.FailureStr,
+		}, err
+	}
 
 	objects := map[string]string{
 		"file":        data.Name,
 		"destination": dstfile,
 	}
 
+	if err := os.MkdirAll(filepath.Dir(dstfile), 0750); err != nil {
+		return utils.LogLine{
+			Objects: objects,
+			Error:   err.Error(),
+			Status:  utils.FailureStr,
+		}, err
+	}
+
 	if err := os.WriteFile(dstfile, data.Bytes, 0600); err != nil {
 		return utils.LogLine{
 			Objects: objects,
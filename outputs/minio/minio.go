@@ -29,7 +29,7 @@ const (
     target: minio:s3
     parameters:
       bucket: falco-talon
-      prefix: /files
+      prefix: /files/${date}/${namespace}/${pod}/${trace_id}
 `
 )
 
@@ -40,6 +40,7 @@ const (
 type Parameters struct {
 	Bucket string `mapstructure:"bucket" validate:"required"`
 	Prefix string `mapstructure:"prefix" validate:""`
+	Key    string `mapstructure:"key" validate:""`
 }
 
 type Output struct{}
@@ -64,6 +65,7 @@ func (o Output) Parameters() models.Parameters {
 	return Parameters{
 		Prefix: "",
 		Bucket: "",
+		Key:    "",
 	}
 }
 
@@ -97,10 +99,9 @@ func (o Output) Run(output *rules.Output, data *models.Data) (utils.LogLine, err
 		}, err
 	}
 
-	parameters.Prefix = strings.TrimSuffix(parameters.Prefix, "/") + "/"
-
-	var key string
+	key := parameters.Key
 	switch {
+	case key != "":
 	case data.Objects["namespace"] != "" && data.Objects["pod"] != "":
 		key = fmt.Sprintf("%v_%v_%v_%v", time.Now().Format("2006-01-02T15-04-05Z"), data.Objects["namespace"], data.Objects["pod"], strings.ReplaceAll(data.Name, "/", "_"))
 	case data.Objects["hostname"] != "":
@@ -115,6 +116,19 @@ func (o Output) Run(output *rules.Output, data *models.Data) (utils.LogLine, err
 		key = fmt.Sprintf("%v_%v%v", time.Now().Format("2006-01-02T15-04-05Z"), s, strings.ReplaceAll(data.Name, "/", "_"))
 	}
 
+	// the values of the events are escaped in the parameters, the prefix and the key from the rule
+	// are still checked to not write outside of the prefix
+	full, err := output.CheckPath("prefix", strings.TrimSuffix(parameters.Prefix, "/")+"/"+key)
+	if err != nil {
+		return utils.LogLine{
+			Objects: map[string]string{"file": data.Name, "bucket": parameters.Bucket},
+			Error:   err.Error(),
+			Status:  utils.FailureStr,
+		}, err
+	}
+	i := strings.LastIndex(full, "/")
+	parameters.Prefix, key = full[:i+1], full[i+1:]
+
 	objects := map[string]string{
 		"file":   data.Name,
 		"bucket": parameters.Bucket,
@@ -9,6 +9,7 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	validator "github.com/go-playground/validator/v10"
@@ -37,8 +38,15 @@ const (
 	textStr  string = "text"
 	colorStr string = "color"
 
-	SuccessStr string = "success"
-	FailureStr string = "failure"
+	SuccessStr   string = "success"
+	FailureStr   string = "failure"
+	ThrottledStr string = "throttled"
+	TimeoutStr   string = "timeout"
+	RetryStr     string = "retry"
+	PendingStr   string = "pending"
+	ApprovedStr  string = "approved"
+	DeniedStr    string = "denied"
+	RunningStr   string = "running"
 
 	ansiChars string = "[\u001B\u009B][[\\]()#;?]*(?:(?:(?:[a-zA-Z\\d]*(?:;[a-zA-Z\\d]*)*)?\u0007)|(?:(?:\\d{1,4}(?:;\\d{0,4})*)?[\\dA-PRZcf-ntqry=><~]))"
 
@@ -70,9 +78,14 @@ type LogLine struct {
 	Stage        string            `json:"stage,omitempty"`
 }
 
+// LogHook is called for each printed log line
+type LogHook func(level string, line LogLine)
+
 var validate *validator.Validate
 var localIP *string
 var logFormat *string
+var logHooks []LogHook
+var logHooksMutex sync.RWMutex
 
 func init() {
 	logFormat = new(string)
@@ -86,7 +99,19 @@ func SetLogFormat(format string) {
 	}
 }
 
+func AddLogHook(hook LogHook) {
+	logHooksMutex.Lock()
+	defer logHooksMutex.Unlock()
+	logHooks = append(logHooks, hook)
+}
+
 func PrintLog(level string, line LogLine) {
+	logHooksMutex.RLock()
+	for _, i := range logHooks {
+		i(level, line)
+	}
+	logHooksMutex.RUnlock()
+
 	var output zerolog.ConsoleWriter
 
 	var log zerolog.Logger
@@ -303,3 +328,18 @@ func GetLocalIP() *string {
 	}
 	return localIP
 }
+
+// ParseTime parses a RFC3339 date or a duration relative to now (eg: 1h)
+func ParseTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("incorrect time '%v', a RFC3339 date or a duration is expected", s)
+	}
+	return time.Now().Add(-d), nil
+}